@@ -0,0 +1,63 @@
+package command
+
+// The scripts ask sf for completions by running the command line so far with --generate-bash-completion,
+// see Complete. Tags are comma separated, so only the tag after the last comma is completed.
+
+const bashCompletion = `# sf bash completion, load with: source <(sf completion bash)
+
+_sf_completion() {
+  local cur prefix words
+  COMPREPLY=()
+  cur="${COMP_WORDS[COMP_CWORD]}"
+
+  if [[ "$cur" == "-"* ]]; then
+    words=$(SF_COMPLETION=bash SF_COMPLETION_WORD="$cur" "${COMP_WORDS[@]:0:$COMP_CWORD}" "$cur" --generate-bash-completion 2>/dev/null)
+  else
+    words=$(SF_COMPLETION=bash SF_COMPLETION_WORD="$cur" "${COMP_WORDS[@]:0:$COMP_CWORD}" --generate-bash-completion 2>/dev/null)
+  fi
+
+  prefix=""
+  if [[ "$cur" == *,* ]]; then
+    prefix="${cur%,*},"
+    cur="${cur##*,}"
+  fi
+
+  COMPREPLY=( $(compgen -P "$prefix" -W "$words" -- "$cur") )
+}
+
+complete -o bashdefault -o default -F _sf_completion sf
+`
+
+const zshCompletion = `#compdef sf
+# sf zsh completion, load with: source <(sf completion zsh)
+
+_sf() {
+  local -a opts
+  local cur="${words[CURRENT]}"
+
+  if [[ "$cur" == -* ]]; then
+    opts=("${(@f)$(_CLI_ZSH_AUTOCOMPLETE_HACK=1 SF_COMPLETION=zsh SF_COMPLETION_WORD="$cur" ${words[@]:0:#words[@]-1} "$cur" --generate-bash-completion 2>/dev/null)}")
+  else
+    opts=("${(@f)$(_CLI_ZSH_AUTOCOMPLETE_HACK=1 SF_COMPLETION=zsh SF_COMPLETION_WORD="$cur" ${words[@]:0:#words[@]-1} --generate-bash-completion 2>/dev/null)}")
+  fi
+
+  compset -P '*,'
+  _describe 'values' opts
+}
+
+compdef _sf sf
+`
+
+// Appended to the completions urfave/cli generates for fish, which don't know about tags or note IDs.
+const fishCompletion = `
+function __fish_sf_dynamic --description 'Complete tags and note IDs from the journal'
+    set -l word (commandline -ct)
+    set -l prefix (string match -r '^.*,' -- $word)
+    for completion in (env SF_COMPLETION=fish SF_COMPLETION_WORD=$word (commandline -opc) --generate-bash-completion 2>/dev/null)
+        echo $prefix$completion
+    end
+end
+
+complete -c sf -x -s t -l tags -a '(__fish_sf_dynamic)'
+complete -c sf -f -n '__fish_seen_subcommand_from delete d edit e done pin unpin links backlinks attachments reopen cancel' -a '(__fish_sf_dynamic)'
+`
@@ -8,6 +8,24 @@ var (
 	errInvalidNoteId = errors.New("invalid note id")
 	errNoteNotFound  = errors.New("note not found")
 	errInvalidAge    = errors.New("invalid age")
+	errInvalidDate   = errors.New("invalid date, e.g 2019-12-08, today or yesterday")
+	errInvalidMonth  = errors.New("invalid month, e.g 2019-12")
+	errDailyViewOnly = errors.New("only today's daily note can be written to, --date is for viewing")
+	errMissingTag    = errors.New("missing tag")
+	errTagTooLong    = errors.New("tag longer than 50 characters")
+
+	errNothingToRetag = errors.New("nothing to retag from, use --from-content")
+
+	errMissingAttachmentName = errors.New("missing attachment name")
+	errMissingAttachmentFile = errors.New("missing file to attach")
+	errMissingTemplateName   = errors.New("missing template name")
+	errMissingOut            = errors.New("missing output directory, use --out")
+	errInvalidShell          = errors.New("invalid shell, expected bash, zsh or fish")
+
+	errMaintenanceUnsupported = errors.New("storage backend does not support maintenance")
+	errAttachmentsUnsupported = errors.New("storage backend does not support attachments")
+	errReportsUnsupported     = errors.New("storage backend does not support reports")
+	errIntegrityCheckFailed   = errors.New("integrity check failed")
 )
 
 const (
@@ -17,4 +35,27 @@ const (
 	flagDetailed  = "detailed"
 	flagPretty    = "pretty"
 	flagNoConfirm = "no-confirm"
+	flagFix       = "fix"
+	flagTitle     = "title"
+	flagOneLine   = "oneline"
+	flagMeta      = "meta"
+	flagTagTree   = "tag-tree"
+
+	flagFromContent = "from-content"
+	flagAttach      = "attach"
+	flagOutput      = "output"
+	flagDue         = "due"
+	flagWithin      = "within"
+	flagStatus      = "status"
+	flagAll         = "all"
+	flagPinned      = "pinned"
+	flagTemplate    = "template"
+	flagDate        = "date"
+	flagSince       = "since"
+	flagFormat      = "format"
+	flagRange       = "range"
+	flagGroup       = "group"
+	flagOut         = "out"
+	flagFuzzy       = "fuzzy"
+	flagRegex       = "regex"
 )
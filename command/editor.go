@@ -0,0 +1,69 @@
+package command
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Editor used when neither $VISUAL nor $EDITOR are set.
+const defaultEditor = "vi"
+
+type Editor interface {
+	// Open text for editing, returning it once it's saved.
+	Edit(text string) (string, error)
+}
+
+type externalEditor struct{}
+
+func NewEditor() Editor {
+	return externalEditor{}
+}
+
+// Edit text in a temporary file, with the user's $VISUAL or $EDITOR.
+func (editor externalEditor) Edit(text string) (string, error) {
+	file, err := ioutil.TempFile("", "sf-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return "", err
+	}
+
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	// Editors are often configured with arguments, e.g "code --wait".
+	command := strings.Fields(getEditorCommand())
+	cmd := exec.Command(command[0], append(command[1:], file.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", errors.New("editor failed, " + err.Error())
+	}
+
+	edited, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+
+	return string(edited), nil
+}
+
+func getEditorCommand() string {
+	for _, variable := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(variable)); len(editor) > 0 {
+			return editor
+		}
+	}
+
+	return defaultEditor
+}
@@ -0,0 +1,18 @@
+package command
+
+import "github.com/stretchr/testify/mock"
+
+// Create a mock editor to be dependency injected into handler, for the
+// flows which open text in the user's editor.
+type mockEditor struct {
+	mock.Mock
+}
+
+func NewMockEditor() *mockEditor {
+	return &mockEditor{}
+}
+
+func (editor *mockEditor) Edit(text string) (string, error) {
+	args := editor.Called(text)
+	return args.String(0), args.Error(1)
+}
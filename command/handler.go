@@ -2,18 +2,21 @@ package command
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/ricanontherun/short-form/conf"
 	"github.com/ricanontherun/short-form/models"
 	"github.com/ricanontherun/short-form/output"
 	"github.com/ricanontherun/short-form/repository"
-	uuid "github.com/satori/go.uuid"
+	"github.com/ricanontherun/short-form/templates"
+	"github.com/ricanontherun/short-form/utils"
 	"github.com/urfave/cli/v2"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -26,12 +29,24 @@ type handler struct {
 	nowSupplyingFn  nowSupplier
 	inputController UserInputController
 	printer         output.Printer
+	ctx             context.Context
+	tagAliases      map[string]string
+	stripHashtags   bool
+	templates       templates.Store
+	editor          Editor
+	privateTags     []string
 }
 
 type HandlerBuilder struct {
 	repository      repository.Repository
 	nowSupplier     nowSupplier
 	inputController UserInputController
+	ctx             context.Context
+	tagAliases      map[string]string
+	stripHashtags   bool
+	templatesPath   string
+	editor          Editor
+	privateTags     []string
 }
 
 func NewHandlerBuilder(repository repository.Repository) *HandlerBuilder {
@@ -48,6 +63,40 @@ func (builder *HandlerBuilder) WithUserInputController(inputController UserInput
 	return builder
 }
 
+// WithContext sets the context which, when cancelled, stops long running operations.
+func (builder *HandlerBuilder) WithContext(ctx context.Context) *HandlerBuilder {
+	builder.ctx = ctx
+	return builder
+}
+
+// WithTagAliases sets the aliases applied to tags as notes are written and searched.
+func (builder *HandlerBuilder) WithTagAliases(aliases map[string]string) *HandlerBuilder {
+	builder.tagAliases = aliases
+	return builder
+}
+
+// WithStripHashtags sets whether #tags are removed from note content once they're added as tags.
+func (builder *HandlerBuilder) WithStripHashtags(strip bool) *HandlerBuilder {
+	builder.stripHashtags = strip
+	return builder
+}
+
+func (builder *HandlerBuilder) WithTemplatesPath(path string) *HandlerBuilder {
+	builder.templatesPath = path
+	return builder
+}
+
+func (builder *HandlerBuilder) WithEditor(editor Editor) *HandlerBuilder {
+	builder.editor = editor
+	return builder
+}
+
+// WithPrivateTags sets the tags whose notes are left out of published sites.
+func (builder *HandlerBuilder) WithPrivateTags(tags []string) *HandlerBuilder {
+	builder.privateTags = tags
+	return builder
+}
+
 func (builder *HandlerBuilder) Build() handler {
 	handler := handler{}
 
@@ -65,27 +114,127 @@ func (builder *HandlerBuilder) Build() handler {
 		handler.inputController = NewUserInputController()
 	}
 
-	handler.printer = output.NewPrinter()
+	if builder.ctx != nil {
+		handler.ctx = builder.ctx
+	} else {
+		handler.ctx = context.Background()
+	}
+
+	if builder.editor != nil {
+		handler.editor = builder.editor
+	} else {
+		handler.editor = NewEditor()
+	}
+
+	handler.printer = output.NewPrinter(handler.nowSupplyingFn)
+	handler.stripHashtags = builder.stripHashtags
+	handler.templates = templates.NewStore(builder.templatesPath)
+
+	// Aliases are compared against normalized tags.
+	handler.tagAliases = make(map[string]string, len(builder.tagAliases))
+	for alias, tag := range builder.tagAliases {
+		handler.tagAliases[models.NormalizeTag(alias)] = models.NormalizeTag(tag)
+	}
+
+	for _, tag := range builder.privateTags {
+		handler.privateTags = append(handler.privateTags, models.NormalizeTag(tag))
+	}
 
 	return handler
 }
 
+// Replace any aliased tags, dropping duplicates this creates.
+func (handler handler) resolveTags(tags []string) []string {
+	if len(tags) == 0 || len(handler.tagAliases) == 0 {
+		return tags
+	}
+
+	resolved := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		resolved = append(resolved, models.ResolveTagAlias(tag, handler.tagAliases))
+	}
+
+	return utils.SliceUniqueStrings(resolved)
+}
+
+// Merge the #tags written in a note's content into its tags, stripping them
+// from the content if configured to. Returns whether any tags were added.
+func (handler handler) applyHashtags(note *models.Note) bool {
+	hashtags := handler.resolveTags(models.ExtractHashtags(note.Content))
+	if len(hashtags) == 0 {
+		return false
+	}
+
+	tagCount := len(note.Tags)
+	note.Tags = models.MergeTags(note.Tags, hashtags)
+
+	// Notes which are only hashtags keep them as their content.
+	if stripped := models.StripHashtags(note.Content); handler.stripHashtags && len(stripped) > 0 {
+		note.Content = stripped
+	}
+
+	return len(note.Tags) > tagCount
+}
+
 func DefaultNowSupplier() time.Time {
 	return time.Now()
 }
 
 func (handler handler) WriteNote(ctx *cli.Context) error {
+	return handler.writeNoteFromInput(ctx, models.StatusNone)
+}
+
+// Write a note from the command line input, with a task status, see models.Status.
+func (handler handler) writeNoteFromInput(ctx *cli.Context, status models.Status) error {
 	input, err := getContentFromInput(ctx)
 
 	if err != nil {
 		return err
 	}
 
+	if name := ctx.String(flagTemplate); len(name) > 0 {
+		if err := handler.fillFromTemplate(name, input); err != nil {
+			return err
+		}
+	}
+
 	if len(input.content) == 0 {
 		return errEmptyContent
 	}
 
-	if err := handler.repository.WriteNote(models.NewNote(input.tags, input.content)); err != nil {
+	note := models.NewNote(handler.resolveTags(input.tags), input.content)
+	note.Title = input.title
+	note.Meta = input.meta
+	note.Status = status
+	handler.applyHashtags(&note)
+
+	if len(input.due) > 0 {
+		if note.Due, err = models.ParseDue(input.due, handler.nowSupplyingFn()); err != nil {
+			return err
+		}
+	}
+
+	// Files are read before the note is written, so a missing file doesn't leave a note behind.
+	var attacher repository.Attacher
+	var attachments []models.Attachment
+
+	if paths := ctx.StringSlice(flagAttach); len(paths) > 0 {
+		if attacher, err = handler.getAttacher(); err != nil {
+			return err
+		}
+
+		if attachments, err = readAttachments(note.ID, paths); err != nil {
+			return err
+		}
+	}
+
+	if attacher != nil {
+		err = attacher.WriteNoteWithAttachments(handler.ctx, note, attachments)
+	} else {
+		err = handler.repository.WriteNote(handler.ctx, note)
+	}
+
+	if err != nil {
 		return err
 	}
 
@@ -94,80 +243,134 @@ func (handler handler) WriteNote(ctx *cli.Context) error {
 }
 
 func (handler handler) writeNote(note models.Note) error {
-	return handler.repository.WriteNote(note)
+	return handler.repository.WriteNote(handler.ctx, note)
 }
 
 func (handler handler) SearchToday(ctx *cli.Context) error {
 	now := handler.nowSupplyingFn()
 
-	searchFilters := getSearchFiltersFromContext(ctx)
-	dateRange := models.GetRangeToday(now)
-	searchFilters.DateRange = &dateRange
-
-	if notes, err := handler.repository.SearchNotes(searchFilters); err != nil {
+	searchFilters, err := getSearchFiltersFromContext(ctx)
+	if err != nil {
 		return err
-	} else {
-		handler.printer.PrintNotes(notes, getPrintOptionsFromContext(ctx))
 	}
 
-	return nil
+	dateRange := models.GetRangeToday(now)
+	searchFilters.DateRange = &dateRange
+
+	return handler.searchAndPrintNotes(searchFilters, getPrintOptionsFromContext(ctx))
 }
 
 func (handler handler) SearchYesterday(ctx *cli.Context) error {
-	baseFilters := getSearchFiltersFromContext(ctx)
+	baseFilters, err := getSearchFiltersFromContext(ctx)
+	if err != nil {
+		return err
+	}
 
 	dateRange := models.GetRangeYesterday(handler.nowSupplyingFn())
 	baseFilters.DateRange = &dateRange
 
-	if notes, err := handler.repository.SearchNotes(baseFilters); err != nil {
+	return handler.searchAndPrintNotes(baseFilters, getPrintOptionsFromContext(ctx))
+}
+
+func (handler handler) SearchNotes(ctx *cli.Context) error {
+	searchFilters, err := getSearchFiltersFromContext(ctx)
+	if err != nil {
 		return err
-	} else {
-		handler.printer.PrintNotes(notes, getPrintOptionsFromContext(ctx))
 	}
 
-	return nil
-}
+	searchFilters.DateRange, err = handler.getDateRangeFromAge(ctx)
+	if err != nil {
+		return err
+	}
 
-func (handler handler) SearchNotes(ctx *cli.Context) error {
-	searchFilters := getSearchFiltersFromContext(ctx)
+	return handler.searchAndPrintNotes(searchFilters, getPrintOptionsFromContext(ctx))
+}
 
+// Read --age as the range of days before now, nil when there's no age.
+func (handler handler) getDateRangeFromAge(ctx *cli.Context) (*models.DateRange, error) {
 	age := strings.ToLower(ctx.String(flagAge))
-	if len(age) > 0 {
-		validAge := regexp.MustCompile(`^\d+d$`)
-		if !validAge.MatchString(age) {
-			return errInvalidAge
-		} else {
-			ageDays, _ := strconv.Atoi(strings.TrimRight(age, "d"))
-			end := handler.nowSupplyingFn()
-			start := end.AddDate(0, 0, -ageDays)
-
-			searchFilters.DateRange = &models.DateRange{
-				From: start,
-				To:   end,
-			}
-		}
+	if len(age) == 0 {
+		return nil, nil
 	}
 
-	if notes, err := handler.repository.SearchNotes(searchFilters); err != nil {
+	validAge := regexp.MustCompile(`^\d+d$`)
+	if !validAge.MatchString(age) {
+		return nil, errInvalidAge
+	}
+
+	ageDays, _ := strconv.Atoi(strings.TrimRight(age, "d"))
+	end := handler.nowSupplyingFn()
+	start := end.AddDate(0, 0, -ageDays)
+
+	return &models.DateRange{
+		From: start,
+		To:   end,
+	}, nil
+}
+
+// Print notes loosely matching filters, pinned notes then the closest matches first, with the words which
+// matched highlighted. Unlike searchAndPrintNotes, which streams, every match is held in memory to be ranked.
+func (handler handler) searchAndPrintFuzzyNotes(filters models.SearchFilters, options output.Options) error {
+	notes, err := handler.repository.SearchNotes(handler.ctx, filters)
+	if err != nil {
 		return err
-	} else {
-		handler.printer.PrintNotes(notes, getPrintOptionsFromContext(ctx))
 	}
 
+	matches := make(map[*models.Note]models.FuzzyMatch, len(notes))
+	for _, note := range notes {
+		matches[note], _ = models.MatchFuzzy(note.Content, filters.Fuzzy)
+	}
+
+	// Notes matching equally well keep their usual order.
+	sort.SliceStable(notes, func(i, j int) bool {
+		if notes[i].Pinned != notes[j].Pinned {
+			return notes[i].Pinned
+		}
+
+		return matches[notes[i]].Score > matches[notes[j]].Score
+	})
+
+	for _, note := range notes {
+		options.Highlights = matches[note].Spans
+		handler.printer.PrintNote(note, options)
+	}
+
+	handler.printer.PrintNoteCount(len(notes))
 	return nil
 }
 
-func (handler handler) DeleteNote(ctx *cli.Context) error {
-	noteId := strings.TrimSpace(ctx.Args().First())
-	if len(noteId) <= 0 {
-		return errMissingNoteId
+// Print notes matching filters as they're found, followed by how many were found.
+func (handler handler) searchAndPrintNotes(filters models.SearchFilters, options output.Options) error {
+	filters.Tags = handler.resolveTags(filters.Tags)
+	options.SearchTags = filters.Tags
+
+	if len(filters.Fuzzy) > 0 {
+		return handler.searchAndPrintFuzzyNotes(filters, options)
 	}
 
-	if _, err := uuid.FromString(noteId); err != nil {
-		return errInvalidNoteId
+	noteCount := 0
+
+	err := handler.repository.SearchNotesFunc(handler.ctx, filters, func(note *models.Note) error {
+		handler.printer.PrintNote(note, options)
+		noteCount++
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	handler.printer.PrintNoteCount(noteCount)
+	return nil
+}
+
+func (handler handler) DeleteNote(ctx *cli.Context) error {
+	noteId, err := getNoteIdFromContext(ctx)
+	if err != nil {
+		return err
 	}
 
-	if _, err := handler.repository.LookupNote(noteId); err != nil {
+	if _, err := handler.repository.LookupNote(handler.ctx, noteId); err != nil {
 		return err
 	}
 
@@ -178,7 +381,7 @@ func (handler handler) DeleteNote(ctx *cli.Context) error {
 		}
 	}
 
-	if err := handler.repository.DeleteNote(noteId); err != nil {
+	if err := handler.repository.DeleteNote(handler.ctx, noteId); err != nil {
 		return err
 	} else {
 		fmt.Println("ok")
@@ -188,17 +391,12 @@ func (handler handler) DeleteNote(ctx *cli.Context) error {
 }
 
 func (handler handler) EditNote(ctx *cli.Context) error {
-	noteId := ctx.Args().First()
-
-	if len(noteId) == 0 {
-		return errMissingNoteId
-	}
-
-	if _, err := uuid.FromString(noteId); err != nil {
-		return errInvalidNoteId
+	noteId, err := getNoteIdFromContext(ctx)
+	if err != nil {
+		return err
 	}
 
-	note, err := handler.repository.LookupNoteWithTags(noteId)
+	note, err := handler.repository.LookupNoteWithTags(handler.ctx, noteId)
 	if err != nil {
 		if err == repository.ErrNoteNotFound {
 			return errNoteNotFound
@@ -209,6 +407,7 @@ func (handler handler) EditNote(ctx *cli.Context) error {
 
 	contentChanged := false
 	tagsChanged := false
+	titleChanged := false
 
 	if handler.makeUserConfirmAction("update content?") {
 		newContent := handler.promptUser("new content: ")
@@ -224,18 +423,39 @@ func (handler handler) EditNote(ctx *cli.Context) error {
 
 		if len(newTagsString) != 0 {
 			tagsChanged = true
-			note.Tags = cleanTagsFromString(newTagsString)
+			note.Tags = handler.resolveTags(cleanTagsFromString(newTagsString))
 		}
 	}
 
-	if contentChanged {
-		if err := handler.repository.UpdateNote(*note); err != nil {
+	if handler.makeUserConfirmAction("update title?") {
+		titleChanged = true
+		note.Title = handler.promptUser("new title (empty to use the first line): ")
+	}
+
+	dueChanged := false
+	if handler.makeUserConfirmAction("update due date?") {
+		due := handler.promptUser("new due date, e.g tomorrow 9am (empty for none): ")
+		dueChanged = true
+
+		if len(due) == 0 {
+			note.Due = time.Time{}
+		} else if note.Due, err = models.ParseDue(due, handler.nowSupplyingFn()); err != nil {
+			return err
+		}
+	}
+
+	if contentChanged && handler.applyHashtags(note) {
+		tagsChanged = true
+	}
+
+	if contentChanged || titleChanged || dueChanged {
+		if err := handler.repository.UpdateNote(handler.ctx, *note); err != nil {
 			return err
 		}
 	}
 
 	if tagsChanged {
-		if err := handler.repository.TagNote(*note, note.Tags); err != nil {
+		if err := handler.repository.TagNote(handler.ctx, *note, note.Tags); err != nil {
 			return err
 		}
 	}
@@ -264,8 +484,39 @@ func (handler handler) ConfigureDatabase(cli *cli.Context, conf conf.Config) err
 	return conf.Save()
 }
 
+func (handler handler) ConfigureStorage(cli *cli.Context, conf conf.Config) error {
+	backend := strings.ToLower(strings.TrimSpace(cli.String("backend")))
+	if !utils.SliceContainsElement(backend, repository.Backends()) {
+		return fmt.Errorf("invalid backend '%s', expected one of: %s", backend, strings.Join(repository.Backends(), ", "))
+	}
+
+	if err := conf.SetStorage(backend); err != nil {
+		return err
+	}
+
+	if path := cli.String("path"); len(path) > 0 {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+
+		switch backend {
+		case repository.BackendSqlite:
+			err = conf.SetDatabasePath(path)
+		case repository.BackendFiles:
+			err = conf.SetFilesPath(path)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return conf.Save()
+}
+
 func (handler handler) StreamNotes(cli *cli.Context) error {
-	tags := getTagsFromContext(cli)
+	tags := handler.resolveTags(getTagsFromContext(cli))
 	input := ""
 	reader := bufio.NewReader(os.Stdin)
 
@@ -274,6 +525,12 @@ func (handler handler) StreamNotes(cli *cli.Context) error {
 	for {
 		fmt.Print("-> ")
 		input, _ = reader.ReadString('\n')
+
+		// Interrupted whilst waiting for the next note.
+		if err := handler.ctx.Err(); err != nil {
+			return err
+		}
+
 		trimmedInput := strings.Replace(strings.TrimSpace(input), "\n", "", -1)
 
 		if len(trimmedInput) == 0 {
@@ -281,7 +538,9 @@ func (handler handler) StreamNotes(cli *cli.Context) error {
 		}
 
 		note := models.NewNote(tags, trimmedInput)
-		if err := handler.repository.WriteNote(note); err != nil {
+		handler.applyHashtags(&note)
+
+		if err := handler.repository.WriteNote(handler.ctx, note); err != nil {
 			log.Println("failed to save note: " + err.Error())
 		}
 	}
@@ -0,0 +1,198 @@
+package command
+
+import (
+	"fmt"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/ricanontherun/short-form/repository"
+	"github.com/ricanontherun/short-form/utils"
+	"github.com/urfave/cli/v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func (handler handler) getAttacher() (repository.Attacher, error) {
+	if attacher, ok := handler.repository.(repository.Attacher); ok {
+		return attacher, nil
+	}
+
+	return nil, errAttachmentsUnsupported
+}
+
+// Read files to attach to a note. Files are named by their base name.
+func readAttachments(noteId string, paths []string) ([]models.Attachment, error) {
+	attachments := make([]models.Attachment, 0, len(paths))
+	names := utils.NewSet()
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		name := filepath.Base(path)
+		if names.Has(name) {
+			return nil, repository.ErrAttachmentExists
+		}
+		names.Add(name)
+
+		attachments = append(attachments, models.NewAttachment(noteId, name, data))
+	}
+
+	return attachments, nil
+}
+
+// Attach files to an existing note. Either every file is attached, or none are.
+func (handler handler) AddAttachments(ctx *cli.Context) error {
+	attacher, err := handler.getAttacher()
+	if err != nil {
+		return err
+	}
+
+	noteId, err := getNoteIdFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	paths := ctx.Args().Tail()
+	if len(paths) == 0 {
+		return errMissingAttachmentFile
+	}
+
+	attachments, err := readAttachments(noteId, paths)
+	if err != nil {
+		return err
+	}
+
+	if err := attacher.WriteAttachments(handler.ctx, attachments); err != nil {
+		if err == repository.ErrNoteNotFound {
+			return errNoteNotFound
+		}
+
+		return err
+	}
+
+	if len(attachments) == 1 {
+		fmt.Println("1 file attached")
+	} else {
+		fmt.Printf("%d files attached\n", len(attachments))
+	}
+
+	return nil
+}
+
+// List a note's attachments.
+func (handler handler) ListAttachments(ctx *cli.Context) error {
+	attacher, err := handler.getAttacher()
+	if err != nil {
+		return err
+	}
+
+	noteId, err := getNoteIdFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := handler.repository.LookupNote(handler.ctx, noteId); err != nil {
+		if err == repository.ErrNoteNotFound {
+			return errNoteNotFound
+		}
+
+		return err
+	}
+
+	attachments, err := attacher.ListAttachments(handler.ctx, noteId)
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		fmt.Printf("%s | %s | %s | %s\n",
+			attachment.Name,
+			utils.FormatBytes(attachment.Size),
+			attachment.Timestamp.Format("Jan 02 2006 03:04 PM"),
+			attachment.Hash[:12],
+		)
+	}
+
+	if len(attachments) == 1 {
+		fmt.Println("1 attachment found")
+	} else {
+		fmt.Printf("%d attachments found\n", len(attachments))
+	}
+
+	return nil
+}
+
+// Flags aren't parsed after arguments, so an output given as "ID NAME -o PATH" is read here.
+// Returns the remaining arguments, and the output if one was given.
+func splitTrailingOutput(args []string) ([]string, string) {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == "-o" || args[i] == "--"+flagOutput {
+			return append(append([]string{}, args[:i]...), args[i+2:]...), args[i+1]
+		}
+	}
+
+	return args, ""
+}
+
+// Write one of a note's attachments to a file, or stdout when the output is "-".
+func (handler handler) GetAttachment(ctx *cli.Context) error {
+	attacher, err := handler.getAttacher()
+	if err != nil {
+		return err
+	}
+
+	noteId, err := getNoteIdFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	args, output := splitTrailingOutput(ctx.Args().Slice())
+
+	name := ""
+	if len(args) > 1 {
+		name = strings.TrimSpace(args[1])
+	}
+
+	if len(name) == 0 {
+		return errMissingAttachmentName
+	}
+
+	attachment, err := attacher.ReadAttachment(handler.ctx, noteId, name)
+	if err != nil {
+		return err
+	}
+
+	if len(output) == 0 {
+		output = strings.TrimSpace(ctx.String(flagOutput))
+	}
+
+	if len(output) == 0 {
+		output = attachment.Name
+	}
+
+	if output == "-" {
+		_, err := os.Stdout.Write(attachment.Data)
+		return err
+	}
+
+	// Never overwrite an existing file.
+	file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(attachment.Data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s written to %s\n", attachment.Name, output)
+	return nil
+}
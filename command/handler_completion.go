@@ -0,0 +1,119 @@
+package command
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"io"
+	"os"
+	"strings"
+)
+
+// Set by the completion scripts, to the shell completing and the word being completed.
+const (
+	completionShellVariable = "SF_COMPLETION"
+	completionWordVariable  = "SF_COMPLETION_WORD"
+)
+
+// How many of the latest notes are offered when completing a note ID.
+const completionNoteLimit = 20
+
+// How much of a note's content is shown beside its ID, by shells which show descriptions.
+const completionPreviewLength = 50
+
+// Print a script which completes sf's commands, flags, tags and note IDs in a shell.
+func (handler handler) Completion(ctx *cli.Context) error {
+	switch shell := strings.ToLower(ctx.Args().First()); shell {
+	case "bash":
+		fmt.Print(bashCompletion)
+	case "zsh":
+		fmt.Print(zshCompletion)
+	case "fish":
+		script, err := ctx.App.ToFishCompletion()
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(script + fishCompletion)
+	default:
+		return errInvalidShell
+	}
+
+	return nil
+}
+
+// Complete a command's flags and subcommands, or existing tags after --tags.
+func (handler handler) Complete(ctx *cli.Context) {
+	if isTagFlag(previousCompletionWord()) {
+		_ = handler.writeTagCompletions(ctx.App.Writer, os.Getenv(completionShellVariable))
+		return
+	}
+
+	cli.DefaultCompleteWithFlags(ctx.Command)(ctx)
+}
+
+// Complete the IDs of the latest notes, for commands which take a note ID.
+func (handler handler) CompleteNoteIds(ctx *cli.Context) {
+	if strings.HasPrefix(os.Getenv(completionWordVariable), "-") || isTagFlag(previousCompletionWord()) {
+		handler.Complete(ctx)
+		return
+	}
+
+	_ = handler.writeNoteIdCompletions(ctx.App.Writer, os.Getenv(completionShellVariable))
+}
+
+// The word before the one being completed. Completion is requested by adding a flag after it.
+func previousCompletionWord() string {
+	if len(os.Args) < 3 {
+		return ""
+	}
+
+	return os.Args[len(os.Args)-2]
+}
+
+func isTagFlag(word string) bool {
+	return word == "-t" || word == "--"+flagTags
+}
+
+func (handler handler) writeTagCompletions(writer io.Writer, shell string) error {
+	tags, err := handler.repository.ListTags(handler.ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		writeCompletion(writer, shell, tag.Tag, fmt.Sprintf("%d notes", tag.Count))
+	}
+
+	return nil
+}
+
+// Note IDs are offered newest first, beside the start of each note's content.
+func (handler handler) writeNoteIdCompletions(writer io.Writer, shell string) error {
+	notes, err := handler.repository.LatestNotes(handler.ctx, completionNoteLimit)
+	if err != nil {
+		return err
+	}
+
+	for _, note := range notes {
+		preview := strings.Join(strings.Fields(note.GetTitle()), " ")
+		if runes := []rune(preview); len(runes) > completionPreviewLength {
+			preview = string(runes[:completionPreviewLength-3]) + "..."
+		}
+
+		writeCompletion(writer, shell, note.ID, preview)
+	}
+
+	return nil
+}
+
+// Write a completion as the shell reads them, zsh and fish show a description beside each.
+func writeCompletion(writer io.Writer, shell string, value string, description string) {
+	switch shell {
+	case "zsh":
+		_, _ = fmt.Fprintf(writer, "%s:%s\n", strings.Replace(value, ":", `\:`, -1), description)
+	case "fish":
+		_, _ = fmt.Fprintf(writer, "%s\t%s\n", value, description)
+	default:
+		_, _ = fmt.Fprintln(writer, value)
+	}
+}
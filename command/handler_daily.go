@@ -0,0 +1,171 @@
+package command
+
+import (
+	"fmt"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/urfave/cli/v2"
+	"strings"
+	"time"
+)
+
+// Parse a day given on the command line, either today, yesterday or a date such as 2019-12-08.
+func parseDay(expression string, now time.Time) (time.Time, error) {
+	switch strings.ToLower(strings.TrimSpace(expression)) {
+	case "today":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+
+	day, err := time.ParseInLocation(models.DailyDateFormat, strings.TrimSpace(expression), now.Location())
+	if err != nil {
+		return day, errInvalidDate
+	}
+
+	return day, nil
+}
+
+// Find the daily note for a day, nil when it hasn't been written.
+func (handler handler) findDailyNote(day time.Time) (*models.Note, error) {
+	notes, err := handler.repository.SearchNotes(handler.ctx, models.DailyFilters(day))
+	if err != nil || len(notes) == 0 {
+		return nil, err
+	}
+
+	return notes[0], nil
+}
+
+// Append to today's daily note, or open it in the editor when nothing is given to append.
+// Past days can be viewed with --date.
+func (handler handler) Daily(ctx *cli.Context) error {
+	now := handler.nowSupplyingFn()
+
+	if date := ctx.String(flagDate); len(date) > 0 {
+		if ctx.Args().Len() > 0 {
+			return errDailyViewOnly
+		}
+
+		day, err := parseDay(date, now)
+		if err != nil {
+			return err
+		}
+
+		return handler.printDailyNote(ctx, day)
+	}
+
+	note, err := handler.findDailyNote(now)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Args().Len() > 0 {
+		// Each appended line is a timestamped entry in the day's log.
+		line := now.Format("15:04") + " " + strings.Join(ctx.Args().Slice(), " ")
+		return handler.saveDailyNote(note, now, line, true)
+	}
+
+	content := ""
+	if note != nil {
+		content = note.Content
+	}
+
+	edited, err := handler.editor.Edit(content + "\n")
+	if err != nil {
+		return err
+	}
+
+	edited = strings.TrimSpace(edited)
+	if len(edited) == 0 {
+		return errEmptyContent
+	}
+
+	if edited == content {
+		fmt.Println("no changes")
+		return nil
+	}
+
+	return handler.saveDailyNote(note, now, edited, false)
+}
+
+// Write a day's note, creating it if needed, either appending content or replacing it.
+func (handler handler) saveDailyNote(note *models.Note, now time.Time, content string, appendContent bool) error {
+	if note == nil {
+		daily := models.NewDailyNote(now, content)
+		handler.applyHashtags(&daily)
+
+		if err := handler.writeNote(daily); err != nil {
+			return err
+		}
+
+		fmt.Println("note saved")
+		return nil
+	}
+
+	if appendContent {
+		note.Content += "\n" + content
+	} else {
+		note.Content = content
+	}
+
+	tagsChanged := handler.applyHashtags(note)
+
+	if err := handler.repository.UpdateNote(handler.ctx, *note); err != nil {
+		return err
+	}
+
+	if tagsChanged {
+		if err := handler.repository.TagNote(handler.ctx, *note, note.Tags); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("note saved")
+	return nil
+}
+
+func (handler handler) printDailyNote(ctx *cli.Context, day time.Time) error {
+	note, err := handler.findDailyNote(day)
+	if err != nil {
+		return err
+	}
+
+	if note == nil {
+		fmt.Println("no daily note for " + day.Format(models.DailyDateFormat))
+		return nil
+	}
+
+	handler.printer.PrintNote(note, getPrintOptionsFromContext(ctx))
+	return nil
+}
+
+// Show a month's calendar, marking the days which have notes. Defaults to this month.
+func (handler handler) Calendar(ctx *cli.Context) error {
+	now := handler.nowSupplyingFn()
+	month := now
+
+	if expression := strings.TrimSpace(ctx.Args().First()); len(expression) > 0 {
+		var err error
+		if month, err = time.ParseInLocation("2006-01", expression, now.Location()); err != nil {
+			return errInvalidMonth
+		}
+	}
+
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	dateRange := models.DateRange{
+		From: first,
+		To:   models.GetRangeToday(first.AddDate(0, 1, -1)).To,
+	}
+
+	counts := make(map[int]int)
+	err := handler.repository.SearchNotesFunc(handler.ctx, models.SearchFilters{DateRange: &dateRange}, func(note *models.Note) error {
+		counts[note.Timestamp.In(first.Location()).Day()]++
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	handler.printer.PrintCalendar(first, counts, getPrintOptionsFromContext(ctx))
+	return nil
+}
@@ -0,0 +1,134 @@
+package command
+
+import (
+	"fmt"
+	"github.com/ricanontherun/short-form/repository"
+	"github.com/ricanontherun/short-form/utils"
+	"github.com/urfave/cli/v2"
+	"strings"
+)
+
+// How many of the largest notes to list in database stats.
+const statsLargestNotes = 5
+
+func (handler handler) getMaintainer() (repository.Maintainer, error) {
+	if maintainer, ok := handler.repository.(repository.Maintainer); ok {
+		return maintainer, nil
+	}
+
+	return nil, errMaintenanceUnsupported
+}
+
+func (handler handler) CheckDatabase(ctx *cli.Context) error {
+	maintainer, err := handler.getMaintainer()
+	if err != nil {
+		return err
+	}
+
+	report, err := maintainer.CheckIntegrity(handler.ctx, ctx.Bool(flagFix))
+	if err != nil {
+		return err
+	}
+
+	for _, problem := range report.Problems {
+		fmt.Println("integrity: " + problem)
+	}
+
+	if len(report.OrphanTags) > 0 {
+		fmt.Printf("%d orphaned tag(s) found\n", len(report.OrphanTags))
+
+		for _, orphan := range report.OrphanTags {
+			fmt.Printf("  %s | %s\n", orphan.NoteID, orphan.Tag)
+		}
+
+		if report.Fixed {
+			fmt.Println("orphaned tags removed")
+		} else {
+			fmt.Println("run with --fix to remove them")
+		}
+	}
+
+	if !report.Ok() {
+		return errIntegrityCheckFailed
+	}
+
+	fmt.Println("ok")
+	return nil
+}
+
+func (handler handler) VacuumDatabase(ctx *cli.Context) error {
+	maintainer, err := handler.getMaintainer()
+	if err != nil {
+		return err
+	}
+
+	if err := maintainer.Vacuum(handler.ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("ok")
+	return nil
+}
+
+func (handler handler) OptimizeDatabase(ctx *cli.Context) error {
+	maintainer, err := handler.getMaintainer()
+	if err != nil {
+		return err
+	}
+
+	if err := maintainer.Optimize(handler.ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("ok")
+	return nil
+}
+
+func (handler handler) DatabaseStats(ctx *cli.Context) error {
+	maintainer, err := handler.getMaintainer()
+	if err != nil {
+		return err
+	}
+
+	stats, err := maintainer.Stats(handler.ctx, statsLargestNotes)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("notes:     %d\n", stats.NoteCount)
+	fmt.Printf("tags:      %d\n", stats.TagCount)
+	fmt.Printf("file size: %s\n", utils.FormatBytes(stats.FileSize))
+
+	if stats.OldestNote != nil {
+		fmt.Printf("oldest:    %s\n", stats.OldestNote.Format("Jan 02 2006 03:04 PM"))
+	}
+
+	if stats.NewestNote != nil {
+		fmt.Printf("newest:    %s\n", stats.NewestNote.Format("Jan 02 2006 03:04 PM"))
+	}
+
+	if len(stats.LargestNotes) > 0 {
+		fmt.Println()
+		fmt.Println("largest notes:")
+
+		for _, note := range stats.LargestNotes {
+			fmt.Printf("  %s | %s | %s\n", note.ID, utils.FormatBytes(int64(len(note.Content))), previewContent(note.Content))
+		}
+	}
+
+	return nil
+}
+
+// Maximum length of a single line note preview.
+const previewLength = 50
+
+// previewContent shortens content to a single line preview.
+func previewContent(content string) string {
+	preview := strings.Join(strings.Fields(content), " ")
+
+	if runes := []rune(preview); len(runes) > previewLength {
+		preview = string(runes[:previewLength]) + "..."
+	}
+
+	return preview
+}
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"fmt"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/ricanontherun/short-form/templates"
+	"github.com/urfave/cli/v2"
+	"strings"
+)
+
+// The period a digest covers, when not told otherwise.
+const defaultDigestRange = "last-week"
+
+// Print a digest of the notes written over a period, as Markdown or HTML.
+func (handler handler) Digest(ctx *cli.Context) error {
+	filters, err := getSearchFiltersFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	expression := ctx.String(flagRange)
+	if len(expression) == 0 {
+		expression = defaultDigestRange
+	}
+
+	dateRange, err := models.ParseDateRange(expression, handler.nowSupplyingFn())
+	if err != nil {
+		return err
+	}
+
+	filters.DateRange = &dateRange
+	filters.Tags = handler.resolveTags(filters.Tags)
+
+	notes, err := handler.repository.SearchNotes(handler.ctx, filters)
+	if err != nil {
+		return err
+	}
+
+	digest, err := models.BuildDigest(dateRange, notes, strings.ToLower(ctx.String(flagGroup)))
+	if err != nil {
+		return err
+	}
+
+	format := strings.ToLower(ctx.String(flagFormat))
+	switch format {
+	case "", "markdown":
+		format = templates.DigestMarkdown
+	}
+
+	rendered, err := handler.templates.RenderDigest(digest, format)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(rendered)
+	return nil
+}
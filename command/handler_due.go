@@ -0,0 +1,120 @@
+package command
+
+import (
+	"fmt"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/urfave/cli/v2"
+	"sort"
+	"time"
+)
+
+// How far ahead sf due looks for upcoming notes, when not told otherwise.
+const defaultDueWithin = "7d"
+
+// Find incomplete notes due before a given time, soonest first.
+func (handler handler) findDueNotes(before time.Time) ([]*models.Note, error) {
+	notes, err := handler.repository.SearchNotes(handler.ctx, models.SearchFilters{
+		DueBefore:  before,
+		Incomplete: true,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].Due.Before(notes[j].Due)
+	})
+
+	return notes, nil
+}
+
+// Read the --within flag as a length of time, falling back to a default.
+func getWithinFromContext(ctx *cli.Context, fallback string) (time.Duration, error) {
+	within := ctx.String(flagWithin)
+	if len(within) == 0 {
+		within = fallback
+	}
+
+	if within == "0" {
+		return 0, nil
+	}
+
+	return models.ParseOffset(within)
+}
+
+// List overdue notes, followed by notes due soon.
+func (handler handler) ListDue(ctx *cli.Context) error {
+	within, err := getWithinFromContext(ctx, defaultDueWithin)
+	if err != nil {
+		return err
+	}
+
+	now := handler.nowSupplyingFn()
+	notes, err := handler.findDueNotes(now.Add(within))
+	if err != nil {
+		return err
+	}
+
+	options := getPrintOptionsFromContext(ctx)
+	options.OneLine = true
+	options.Detailed = true
+
+	var overdue, upcoming []*models.Note
+	for _, note := range notes {
+		if note.IsOverdue(now) {
+			overdue = append(overdue, note)
+		} else {
+			upcoming = append(upcoming, note)
+		}
+	}
+
+	if len(overdue) > 0 {
+		fmt.Println("overdue:")
+		for _, note := range overdue {
+			handler.printer.PrintNote(note, options)
+		}
+	}
+
+	if len(upcoming) > 0 {
+		if len(overdue) > 0 {
+			fmt.Println()
+		}
+
+		fmt.Println("upcoming:")
+		for _, note := range upcoming {
+			handler.printer.PrintNote(note, options)
+		}
+	}
+
+	handler.printer.PrintNoteCount(len(notes))
+	return nil
+}
+
+// Print the notes which are now due. Nothing is printed when no notes are due,
+// so this can run from cron or a shell prompt.
+func (handler handler) Remind(ctx *cli.Context) error {
+	within, err := getWithinFromContext(ctx, "0")
+	if err != nil {
+		return err
+	}
+
+	notes, err := handler.findDueNotes(handler.nowSupplyingFn().Add(within))
+	if err != nil {
+		return err
+	}
+
+	options := getPrintOptionsFromContext(ctx)
+	options.OneLine = true
+
+	for _, note := range notes {
+		handler.printer.PrintNote(note, options)
+	}
+
+	return nil
+}
+
+// Mark a note complete.
+func (handler handler) CompleteNote(ctx *cli.Context) error {
+	return handler.setNoteStatus(ctx, models.StatusDone)
+}
@@ -0,0 +1,85 @@
+package command
+
+import (
+	"fmt"
+	"github.com/ricanontherun/short-form/output"
+	"github.com/ricanontherun/short-form/repository"
+	uuid "github.com/satori/go.uuid"
+	"github.com/urfave/cli/v2"
+	"strings"
+)
+
+// Read and validate the note ID given as the first argument.
+func getNoteIdFromContext(ctx *cli.Context) (string, error) {
+	noteId := strings.TrimSpace(ctx.Args().First())
+	if len(noteId) == 0 {
+		return "", errMissingNoteId
+	}
+
+	if _, err := uuid.FromString(noteId); err != nil {
+		return "", errInvalidNoteId
+	}
+
+	return noteId, nil
+}
+
+// Options for listing linked notes, one per line with their IDs.
+func getLinkPrintOptions(ctx *cli.Context) output.Options {
+	options := getPrintOptionsFromContext(ctx)
+	options.OneLine = true
+	options.Detailed = true
+
+	return options
+}
+
+// Print the notes a note links to.
+func (handler handler) Links(ctx *cli.Context) error {
+	noteId, err := getNoteIdFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	links, err := handler.repository.Links(handler.ctx, noteId)
+	if err != nil {
+		if err == repository.ErrNoteNotFound {
+			return errNoteNotFound
+		}
+
+		return err
+	}
+
+	options := getLinkPrintOptions(ctx)
+
+	for _, link := range links {
+		if !link.Broken() {
+			handler.printer.PrintNote(link.Note, options)
+		} else if link.Ambiguous {
+			fmt.Printf("[[%s]] | broken, matches more than one note\n", link.Target)
+		} else {
+			fmt.Printf("[[%s]] | broken, no such note\n", link.Target)
+		}
+	}
+
+	return nil
+}
+
+// Print the notes which link to a note.
+func (handler handler) Backlinks(ctx *cli.Context) error {
+	noteId, err := getNoteIdFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	notes, err := handler.repository.Backlinks(handler.ctx, noteId)
+	if err != nil {
+		return err
+	}
+
+	options := getLinkPrintOptions(ctx)
+	for _, note := range notes {
+		handler.printer.PrintNote(note, options)
+	}
+
+	handler.printer.PrintNoteCount(len(notes))
+	return nil
+}
@@ -0,0 +1,46 @@
+package command
+
+import (
+	"fmt"
+	"github.com/ricanontherun/short-form/repository"
+	"github.com/urfave/cli/v2"
+)
+
+// Pin a note, so it's listed before others.
+func (handler handler) PinNote(ctx *cli.Context) error {
+	return handler.pinNote(ctx, true)
+}
+
+func (handler handler) UnpinNote(ctx *cli.Context) error {
+	return handler.pinNote(ctx, false)
+}
+
+// List pinned notes, narrowed by any other search filters.
+func (handler handler) ListPins(ctx *cli.Context) error {
+	filters, err := getSearchFiltersFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	filters.Pinned = true
+
+	return handler.searchAndPrintNotes(filters, getPrintOptionsFromContext(ctx))
+}
+
+func (handler handler) pinNote(ctx *cli.Context, pinned bool) error {
+	noteId, err := getNoteIdFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := handler.repository.PinNote(handler.ctx, noteId, pinned); err != nil {
+		if err == repository.ErrNoteNotFound {
+			return errNoteNotFound
+		}
+
+		return err
+	}
+
+	fmt.Println("ok")
+	return nil
+}
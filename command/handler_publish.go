@@ -0,0 +1,48 @@
+package command
+
+import (
+	"fmt"
+	"github.com/ricanontherun/short-form/publish"
+	"github.com/ricanontherun/short-form/repository"
+	"github.com/urfave/cli/v2"
+	"strings"
+)
+
+// Write notes matching the search flags to a static site, leaving out notes with private tags.
+func (handler handler) Publish(ctx *cli.Context) error {
+	out := strings.TrimSpace(ctx.String(flagOut))
+	if len(out) == 0 {
+		return errMissingOut
+	}
+
+	filters, err := getSearchFiltersFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	filters.DateRange, err = handler.getDateRangeFromAge(ctx)
+	if err != nil {
+		return err
+	}
+
+	filters.Tags = handler.resolveTags(filters.Tags)
+
+	notes, err := handler.repository.SearchNotes(handler.ctx, filters)
+	if err != nil {
+		return err
+	}
+
+	notes = publish.WithoutPrivate(notes, handler.privateTags)
+
+	site := publish.NewSite(out)
+	if attacher, ok := handler.repository.(repository.Attacher); ok {
+		site = site.WithAttachments(handler.ctx, attacher)
+	}
+
+	if err := site.Write(notes); err != nil {
+		return err
+	}
+
+	fmt.Printf("published %d notes to %s\n", len(notes), out)
+	return nil
+}
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"github.com/ricanontherun/short-form/models"
+	"github.com/ricanontherun/short-form/repository"
+	"github.com/urfave/cli/v2"
+	"strings"
+)
+
+// How far back a report looks, when not told otherwise.
+const defaultReportSince = "7d"
+
+func (handler handler) getReporter() (repository.Reporter, error) {
+	if reporter, ok := handler.repository.(repository.Reporter); ok {
+		return reporter, nil
+	}
+
+	return nil, errReportsUnsupported
+}
+
+// Summarise the notes written recently.
+func (handler handler) Report(ctx *cli.Context) error {
+	reporter, err := handler.getReporter()
+	if err != nil {
+		return err
+	}
+
+	since := ctx.String(flagSince)
+	if len(since) == 0 {
+		since = defaultReportSince
+	}
+
+	period, err := models.ParseOffset(since)
+	if err != nil {
+		return err
+	}
+
+	until := handler.nowSupplyingFn()
+	report, err := reporter.Report(handler.ctx, until.Add(-period), until)
+	if err != nil {
+		return err
+	}
+
+	return handler.printer.PrintReport(report, strings.ToLower(ctx.String(flagFormat)))
+}
@@ -0,0 +1,185 @@
+package command
+
+import (
+	"fmt"
+	"github.com/ricanontherun/short-form/conf"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/urfave/cli/v2"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// List every tag, nested beneath its parent tags.
+func (handler handler) ListTags(ctx *cli.Context) error {
+	tags, err := handler.repository.ListTags(handler.ctx)
+	if err != nil {
+		return err
+	}
+
+	printTagTree(models.BuildTagTree(tags), 0)
+	return nil
+}
+
+func printTagTree(nodes []*models.TagNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	for _, node := range nodes {
+		if node.Count > 0 {
+			fmt.Printf("%s%s (%d)\n", indent, node.Name, node.Count)
+		} else {
+			fmt.Println(indent + node.Name)
+		}
+
+		printTagTree(node.Children, depth+1)
+	}
+}
+
+// Rename a tag, along with every tag beneath it.
+func (handler handler) RenameTag(ctx *cli.Context) error {
+	from := models.NormalizeTag(ctx.Args().Get(0))
+	to := models.NormalizeTag(ctx.Args().Get(1))
+
+	if len(from) == 0 || len(to) == 0 {
+		return errMissingTag
+	}
+
+	if err := handler.checkRenamedTags(from, to); err != nil {
+		return err
+	}
+
+	if !ctx.Bool(flagNoConfirm) {
+		message := fmt.Sprintf("This will rename '%s' and every tag beneath it to '%s', are you sure?", from, to)
+		if ok := handler.makeUserConfirmAction(message); !ok {
+			fmt.Println("cancelled")
+			return nil
+		}
+	}
+
+	renamed, err := handler.repository.RenameTag(handler.ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	if renamed == 1 {
+		fmt.Println("1 note retagged")
+	} else {
+		fmt.Printf("%d notes retagged\n", renamed)
+	}
+
+	return nil
+}
+
+// Check the tags beneath from still fit the tag length once they're renamed to to.
+// NormalizeTag truncates, which would merge tags rather than rename them.
+func (handler handler) checkRenamedTags(from string, to string) error {
+	tags, err := handler.repository.ListTags(handler.ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		if renamed, ok := models.RenameTagTree(tag.Tag, from, to); ok && utf8.RuneCountInString(renamed) > models.MaxTagLength {
+			return fmt.Errorf("%w: %s", errTagTooLong, renamed)
+		}
+	}
+
+	return nil
+}
+
+// Alias one tag to another, or list the aliases when none is given.
+func (handler handler) AliasTag(cli *cli.Context, conf conf.Config) error {
+	alias := models.NormalizeTag(cli.Args().Get(0))
+	tag := models.NormalizeTag(cli.Args().Get(1))
+
+	if cli.Args().Len() == 0 {
+		printTagAliases(conf.GetTagAliases())
+		return nil
+	}
+
+	if len(alias) == 0 || len(tag) == 0 {
+		return errMissingTag
+	}
+
+	// Existing tags beneath the alias are resolved through it as they're searched and retagged.
+	if err := handler.checkRenamedTags(alias, tag); err != nil {
+		return err
+	}
+
+	if err := conf.SetTagAlias(alias, tag); err != nil {
+		return err
+	}
+
+	if err := conf.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("'%s' is now an alias of '%s'. Existing notes keep their tags, retag them with: sf tags mv %s %s\n", alias, tag, alias, tag)
+	return nil
+}
+
+func printTagAliases(aliases map[string]string) {
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+
+	for _, alias := range names {
+		fmt.Printf("%s -> %s\n", alias, aliases[alias])
+	}
+}
+
+// Remove a tag alias.
+func (handler handler) UnaliasTag(cli *cli.Context, conf conf.Config) error {
+	alias := models.NormalizeTag(cli.Args().First())
+	if len(alias) == 0 {
+		return errMissingTag
+	}
+
+	if err := conf.RemoveTagAlias(alias); err != nil {
+		return err
+	}
+
+	return conf.Save()
+}
+
+// Add the #tags written in existing notes' content to their tags.
+// Content is left as it is, even when hashtags are configured to be stripped.
+func (handler handler) RetagNotes(ctx *cli.Context) error {
+	if !ctx.Bool(flagFromContent) {
+		return errNothingToRetag
+	}
+
+	var retagged []*models.Note
+
+	// Notes are collected before they're retagged, so no writes are made while reading.
+	err := handler.repository.SearchNotesFunc(handler.ctx, models.SearchFilters{}, func(note *models.Note) error {
+		tags := models.MergeTags(note.Tags, handler.resolveTags(models.ExtractHashtags(note.Content)))
+
+		if len(tags) > len(note.Tags) {
+			note.Tags = tags
+			retagged = append(retagged, note)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, note := range retagged {
+		if err := handler.repository.TagNote(handler.ctx, *note, note.Tags); err != nil {
+			return err
+		}
+	}
+
+	if len(retagged) == 1 {
+		fmt.Println("1 note retagged")
+	} else {
+		fmt.Printf("%d notes retagged\n", len(retagged))
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package command
+
+import (
+	"fmt"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/ricanontherun/short-form/templates"
+	"github.com/ricanontherun/short-form/utils"
+	"github.com/urfave/cli/v2"
+	"strings"
+	"text/template"
+)
+
+// Functions available to templates, e.g {{date}} or {{prompt "Ticket?"}}.
+func (handler handler) templateFuncs() template.FuncMap {
+	now := handler.nowSupplyingFn()
+
+	return template.FuncMap{
+		"date": func() string {
+			return now.Format("2006-01-02")
+		},
+		"time": func() string {
+			return now.Format("15:04")
+		},
+		"yesterday_notes": func() (string, error) {
+			dateRange := models.GetRangeYesterday(now)
+
+			notes, err := handler.repository.SearchNotes(handler.ctx, models.SearchFilters{DateRange: &dateRange})
+			if err != nil {
+				return "", err
+			}
+
+			lines := make([]string, 0, len(notes))
+			for _, note := range notes {
+				lines = append(lines, "- "+note.GetTitle())
+			}
+
+			return strings.Join(lines, "\n"), nil
+		},
+		"prompt": func(message string) string {
+			return handler.promptUser(message + " ")
+		},
+	}
+}
+
+// Render a template, merge it with the note given on the command line, and open the
+// result in the editor. Content given on the command line follows the template's.
+func (handler handler) fillFromTemplate(name string, input *parsedInput) error {
+	text, err := handler.templates.Read(name)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := templates.Render(name, text, handler.templateFuncs(), nil)
+	if err != nil {
+		return err
+	}
+
+	entry := templates.ParseEntry(rendered)
+	entry.Tags = models.MergeTags(normalizeTags(entry.Tags), input.tags)
+
+	if len(input.title) > 0 {
+		entry.Title = input.title
+	}
+
+	if len(input.content) > 0 {
+		entry.Content = strings.TrimSpace(entry.Content + "\n\n" + input.content)
+	}
+
+	edited, err := handler.editor.Edit(entry.Format())
+	if err != nil {
+		return err
+	}
+
+	entry = templates.ParseEntry(edited)
+	input.title = entry.Title
+	input.tags = normalizeTags(entry.Tags)
+	input.content = entry.Content
+
+	return nil
+}
+
+// Normalize tags written in a template or the editor, keeping their order.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = models.NormalizeTag(tag); len(tag) > 0 {
+			normalized = append(normalized, tag)
+		}
+	}
+
+	return utils.SliceUniqueStrings(normalized)
+}
+
+// List the names of every template.
+func (handler handler) ListTemplates(ctx *cli.Context) error {
+	names, err := handler.templates.List()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		fmt.Println(name)
+	}
+
+	if len(names) == 0 {
+		fmt.Println("no templates, create one with sf template new NAME")
+	}
+
+	return nil
+}
+
+// Print a template as it's written, before rendering.
+func (handler handler) ShowTemplate(ctx *cli.Context) error {
+	name := strings.TrimSpace(ctx.Args().First())
+	if len(name) == 0 {
+		return errMissingTemplateName
+	}
+
+	text, err := handler.templates.Read(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(text)
+	return nil
+}
+
+// Write a new template in the editor.
+func (handler handler) NewTemplate(ctx *cli.Context) error {
+	name := strings.TrimSpace(ctx.Args().First())
+	if len(name) == 0 {
+		return errMissingTemplateName
+	}
+
+	// Check before the template is written, rather than after.
+	if _, err := handler.templates.Read(name); err == nil {
+		return templates.ErrTemplateExists
+	} else if err != templates.ErrTemplateNotFound {
+		return err
+	}
+
+	text, err := handler.editor.Edit(templates.Entry{}.Format())
+	if err != nil {
+		return err
+	}
+
+	if err := handler.templates.Create(name, text); err != nil {
+		return err
+	}
+
+	fmt.Println("template saved to " + handler.templates.Path(name))
+	return nil
+}
@@ -2,15 +2,25 @@
 package command
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"github.com/ricanontherun/short-form/models"
+	"github.com/ricanontherun/short-form/output"
 	"github.com/ricanontherun/short-form/repository"
+	"github.com/ricanontherun/short-form/templates"
 	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/urfave/cli/v2"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 )
@@ -56,7 +66,7 @@ func TestHandler_WriteNote(t *testing.T) {
 			inputTags: "  git,   CLI  	",
 			inputArgs: []string{"This", "is", "THE", "CONTENT"},
 			expectedNote: models.Note{
-				Tags:    []string{"git", "CLI"},
+				Tags:    []string{"git", "cli"},
 				Content: "This is THE CONTENT",
 			},
 		},
@@ -134,7 +144,7 @@ func TestHandler_SearchToday(t *testing.T) {
 
 		context := createAppContext(flags, []string{})
 		r := repository.NewMockRepository()
-		r.On("SearchNotes", mock.Anything).Return(nil)
+		r.On("SearchNotesFunc", mock.Anything).Return(nil, nil)
 		h := NewHandlerBuilder(&r).Build()
 
 		// When
@@ -143,7 +153,7 @@ func TestHandler_SearchToday(t *testing.T) {
 		}
 
 		// Then
-		r.AssertNumberOfCalls(t, "SearchNotes", 1)
+		r.AssertNumberOfCalls(t, "SearchNotesFunc", 1)
 
 		sort.Strings(test.expectedTags)
 		filters := r.Calls[0].Arguments.Get(0).(models.SearchFilters)
@@ -190,7 +200,7 @@ func TestHandler_SearchYesterday(t *testing.T) {
 
 		context := createAppContext(flags, []string{})
 		r := repository.NewMockRepository()
-		r.On("SearchNotes", mock.Anything).Return(nil)
+		r.On("SearchNotesFunc", mock.Anything).Return(nil, nil)
 
 		h := NewHandlerBuilder(&r).Build()
 
@@ -200,7 +210,7 @@ func TestHandler_SearchYesterday(t *testing.T) {
 		}
 
 		// Then
-		r.AssertNumberOfCalls(t, "SearchNotes", 1)
+		r.AssertNumberOfCalls(t, "SearchNotesFunc", 1)
 
 		filters := r.Calls[0].Arguments.Get(0).(models.SearchFilters)
 		sort.Strings(test.expectedTags)
@@ -256,7 +266,7 @@ func TestHandler_SearchNotes(t *testing.T) {
 			inputContent: "REBASE",
 			inputAge:     "100d",
 
-			expectedTags:    []string{"git", "CLI", "version-CONTROL"},
+			expectedTags:    []string{"git", "cli", "version-control"},
 			expectedContent: "REBASE",
 			expectedDateRange: &models.DateRange{
 				From: now.AddDate(0, 0, -100),
@@ -275,7 +285,7 @@ func TestHandler_SearchNotes(t *testing.T) {
 		context := createAppContext(flags, []string{})
 
 		r := repository.NewMockRepository()
-		r.On("SearchNotes", mock.Anything).Return(nil)
+		r.On("SearchNotesFunc", mock.Anything).Return(nil, nil)
 		h := NewHandlerBuilder(&r).WithNowSupplier(func() time.Time {
 			return now
 		}).Build()
@@ -284,7 +294,7 @@ func TestHandler_SearchNotes(t *testing.T) {
 		if test.expectedErr != nil {
 			assert.EqualValues(t, test.expectedErr, err)
 		} else {
-			r.AssertNumberOfCalls(t, "SearchNotes", 1)
+			r.AssertNumberOfCalls(t, "SearchNotesFunc", 1)
 			filters := r.Calls[0].Arguments.Get(0).(models.SearchFilters)
 
 			sort.Strings(filters.Tags)
@@ -419,6 +429,32 @@ func TestHandler_EditNote_InvalidNoteNotFound(t *testing.T) {
 }
 
 func TestHandler_EditNote_AcceptInput(t *testing.T) {
+	r := repository.NewMemoryRepository()
+	note := models.NewNote([]string{"music"}, "note content")
+	assert.Nil(t, r.WriteNote(context.Background(), note))
+
+	input := NewMockInput()
+	input.On("GetString").Return("y").Once()
+	input.On("GetString").Return("updated content").Once()
+	input.On("GetString").Return("y").Once()
+	input.On("GetString").Return("jazz, music").Once()
+	input.On("GetString").Return("y").Once()
+	input.On("GetString").Return("Listening Notes").Once()
+	input.On("GetString").Return("y").Once()
+	input.On("GetString").Return("2019-12-20 5pm").Once()
+
+	appContext := createAppContext(map[string]string{}, []string{note.ID})
+	h := NewHandlerBuilder(r).WithUserInputController(input).Build()
+
+	assert.Nil(t, h.EditNote(appContext))
+
+	updated, err := r.LookupNoteWithTags(h.ctx, note.ID)
+	assert.Nil(t, err)
+	sort.Strings(updated.Tags)
+	assert.EqualValues(t, "updated content", updated.Content)
+	assert.EqualValues(t, "Listening Notes", updated.Title)
+	assert.EqualValues(t, time.Date(2019, 12, 20, 17, 0, 0, 0, time.Local), updated.Due)
+	assert.EqualValues(t, []string{"jazz", "music"}, updated.Tags)
 }
 
 // Success case.
@@ -443,3 +479,508 @@ func TestHandler_EditNote(t *testing.T) {
 	assert.Nil(t, err)
 	r.AssertNumberOfCalls(t, "LookupNoteWithTags", 1)
 }
+
+func TestHandler_RenameTag(t *testing.T) {
+	input := NewMockInput()
+	input.On("GetString", mock.Anything).Return("y")
+
+	r := repository.NewMockRepository()
+	r.On("ListTags").Return([]models.TagCount{{Tag: "work", Count: 1}, {Tag: "work/" + strings.Repeat("a", 40), Count: 1}}, nil)
+	r.On("RenameTag", "work", "day-job").Return(2, nil)
+
+	h := NewHandlerBuilder(&r).WithUserInputController(input).Build()
+
+	assert.Nil(t, h.RenameTag(createAppContext(map[string]string{}, []string{" Work ", "Day Job"})))
+	r.AssertCalled(t, "RenameTag", "work", "day-job")
+
+	assert.EqualValues(t, errMissingTag, h.RenameTag(createAppContext(map[string]string{}, []string{"work"})))
+
+	err := h.RenameTag(createAppContext(map[string]string{}, []string{"work", "my-previous-job"}))
+	assert.True(t, errors.Is(err, errTagTooLong))
+	r.AssertNumberOfCalls(t, "RenameTag", 1)
+
+	// Aliases are checked the same way, before the config is touched.
+	err = h.AliasTag(createAppContext(map[string]string{}, []string{"work", "my-previous-job"}), nil)
+	assert.True(t, errors.Is(err, errTagTooLong))
+}
+
+func TestHandler_WriteNote_TagAliases(t *testing.T) {
+	context := createAppContext(map[string]string{"tags": "K8s, kubernetes, k8s/Prod, git"}, []string{"content"})
+
+	r := repository.NewMockRepository()
+	r.On("WriteNote", mock.Anything).Return(nil)
+
+	h := NewHandlerBuilder(&r).WithTagAliases(map[string]string{"K8S": "Kubernetes"}).Build()
+	assert.Nil(t, h.WriteNote(context))
+
+	note := r.Calls[0].Arguments.Get(0).(models.Note)
+	assert.ElementsMatch(t, []string{"kubernetes", "kubernetes/prod", "git"}, note.Tags)
+}
+
+func TestHandler_WriteNote_Hashtags(t *testing.T) {
+	tests := []struct {
+		strip           bool
+		expectedContent string
+	}{
+		{false, "rebase with #git, see https://git-scm.com/docs#rebase"},
+		{true, "rebase with, see https://git-scm.com/docs#rebase"},
+	}
+
+	for _, test := range tests {
+		context := createAppContext(map[string]string{"tags": "cli"}, []string{"rebase with #git, see https://git-scm.com/docs#rebase"})
+
+		r := repository.NewMockRepository()
+		r.On("WriteNote", mock.Anything).Return(nil)
+
+		h := NewHandlerBuilder(&r).WithStripHashtags(test.strip).Build()
+		assert.Nil(t, h.WriteNote(context))
+
+		note := r.Calls[0].Arguments.Get(0).(models.Note)
+		assert.EqualValues(t, []string{"cli", "git"}, note.Tags)
+		assert.EqualValues(t, test.expectedContent, note.Content)
+	}
+}
+
+func TestHandler_RetagNotes(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+
+	tagged := models.NewNote([]string{"git"}, "already #git")
+	untagged := models.NewNote([]string{"cli"}, "needs #Go and #git")
+	assert.Nil(t, repo.WriteNote(ctx, tagged))
+	assert.Nil(t, repo.WriteNote(ctx, untagged))
+
+	h := NewHandlerBuilder(repo).Build()
+
+	assert.EqualValues(t, errNothingToRetag, h.RetagNotes(createAppContext(map[string]string{}, nil)))
+	assert.Nil(t, h.RetagNotes(createAppContext(map[string]string{"from-content": "true"}, nil)))
+
+	found, err := repo.LookupNoteWithTags(ctx, untagged.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"cli", "go", "git"}, found.Tags)
+	assert.EqualValues(t, untagged.Content, found.Content)
+
+	found, err = repo.LookupNoteWithTags(ctx, tagged.ID)
+	assert.Nil(t, err)
+	assert.True(t, found.UpdatedAt.IsZero())
+}
+
+func TestHandler_Links(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+
+	bug := models.NewNote(nil, "the bug")
+	fix := models.NewNote(nil, "fixes [["+bug.ID+"]]")
+	assert.Nil(t, repo.WriteNote(ctx, bug))
+	assert.Nil(t, repo.WriteNote(ctx, fix))
+
+	h := NewHandlerBuilder(repo).Build()
+
+	assert.Nil(t, h.Links(createAppContext(map[string]string{}, []string{fix.ID})))
+	assert.Nil(t, h.Backlinks(createAppContext(map[string]string{}, []string{bug.ID})))
+
+	assert.EqualValues(t, errMissingNoteId, h.Links(createAppContext(map[string]string{}, []string{})))
+	assert.EqualValues(t, errInvalidNoteId, h.Backlinks(createAppContext(map[string]string{}, []string{"not a uuid"})))
+	assert.EqualValues(t, errNoteNotFound, h.Links(createAppContext(map[string]string{}, []string{uuid.NewV4().String()})))
+}
+
+func TestSplitTrailingOutput(t *testing.T) {
+	args, output := splitTrailingOutput([]string{"ID", "app.log", "-o", "/tmp/app.log"})
+	assert.EqualValues(t, []string{"ID", "app.log"}, args)
+	assert.EqualValues(t, "/tmp/app.log", output)
+
+	args, output = splitTrailingOutput([]string{"ID", "app.log"})
+	assert.EqualValues(t, []string{"ID", "app.log"}, args)
+	assert.EqualValues(t, "", output)
+}
+
+func TestHandler_AddAttachments(t *testing.T) {
+	h := NewHandlerBuilder(repository.NewMemoryRepository()).Build()
+	noteId := uuid.NewV4().String()
+
+	assert.EqualValues(t, errAttachmentsUnsupported, h.AddAttachments(createAppContext(map[string]string{}, []string{noteId, "app.log"})))
+}
+
+func TestHandler_WriteNote_AttachUnsupported(t *testing.T) {
+	flagSet := flag.NewFlagSet("tests", flag.ContinueOnError)
+	attach := cli.NewStringSlice()
+	flagSet.Var(attach, "attach", "")
+	assert.Nil(t, flagSet.Parse([]string{"--attach", "app.log", "content"}))
+
+	repo := repository.NewMemoryRepository()
+	h := NewHandlerBuilder(repo).Build()
+
+	assert.EqualValues(t, errAttachmentsUnsupported, h.WriteNote(cli.NewContext(cli.NewApp(), flagSet, nil)))
+
+	notes, err := repo.SearchNotes(context.Background(), models.SearchFilters{})
+	assert.Nil(t, err)
+	assert.Empty(t, notes)
+}
+
+func TestHandler_WriteNote_Due(t *testing.T) {
+	now := time.Date(2019, 12, 11, 14, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		due             string
+		args            []string
+		expectedDue     time.Time
+		expectedContent string
+	}{
+		{"tomorrow", []string{"9am", "call", "the", "dentist"}, time.Date(2019, 12, 12, 9, 0, 0, 0, time.Local), "call the dentist"},
+		{"tomorrow 5pm", []string{"9am", "standup"}, time.Date(2019, 12, 12, 17, 0, 0, 0, time.Local), "9am standup"},
+		{"friday", []string{"renew", "passport"}, time.Date(2019, 12, 13, 9, 0, 0, 0, time.Local), "renew passport"},
+	}
+
+	for _, test := range tests {
+		context := createAppContext(map[string]string{"due": test.due}, test.args)
+
+		r := repository.NewMockRepository()
+		r.On("WriteNote", mock.Anything).Return(nil)
+
+		h := NewHandlerBuilder(&r).WithNowSupplier(func() time.Time { return now }).Build()
+		assert.Nil(t, h.WriteNote(context))
+
+		note := r.Calls[0].Arguments.Get(0).(models.Note)
+		assert.EqualValues(t, test.expectedDue, note.Due, test.due)
+		assert.EqualValues(t, test.expectedContent, note.Content, test.due)
+	}
+
+	r := repository.NewMockRepository()
+	h := NewHandlerBuilder(&r).Build()
+	assert.NotNil(t, h.WriteNote(createAppContext(map[string]string{"due": "someday"}, []string{"content"})))
+	r.AssertNotCalled(t, "WriteNote", mock.Anything)
+}
+
+func TestHandler_CompleteNote(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo := repository.NewMemoryRepository()
+
+	note := models.NewNote(nil, "file taxes")
+	note.Due = now.Add(-time.Hour)
+	assert.Nil(t, repo.WriteNote(ctx, note))
+
+	h := NewHandlerBuilder(repo).WithNowSupplier(func() time.Time { return now }).Build()
+	assert.Nil(t, h.CompleteNote(createAppContext(map[string]string{}, []string{note.ID})))
+	assert.EqualValues(t, errNoteNotFound, h.CompleteNote(createAppContext(map[string]string{}, []string{uuid.NewV4().String()})))
+
+	notes, err := h.findDueNotes(now)
+	assert.Nil(t, err)
+	assert.Empty(t, notes)
+}
+
+func TestHandler_AddTodo(t *testing.T) {
+	r := repository.NewMockRepository()
+	r.On("WriteNote", mock.Anything).Return(nil)
+
+	h := NewHandlerBuilder(&r).Build()
+	assert.Nil(t, h.AddTodo(createAppContext(map[string]string{"tags": "home"}, []string{"buy", "milk"})))
+
+	note := r.Calls[0].Arguments.Get(0).(models.Note)
+	assert.EqualValues(t, models.StatusOpen, note.Status)
+	assert.EqualValues(t, "buy milk", note.Content)
+	assert.EqualValues(t, []string{"home"}, note.Tags)
+}
+
+func TestHandler_ListTodos(t *testing.T) {
+	tests := []struct {
+		flags            map[string]string
+		expectedStatuses []models.Status
+	}{
+		{map[string]string{}, []models.Status{models.StatusOpen}},
+		{map[string]string{"all": "true"}, models.Statuses},
+	}
+
+	for _, test := range tests {
+		r := repository.NewMockRepository()
+		r.On("SearchNotesFunc", mock.Anything).Return(nil, nil)
+
+		h := NewHandlerBuilder(&r).Build()
+		assert.Nil(t, h.ListTodos(createAppContext(test.flags, []string{})))
+
+		filters := r.Calls[0].Arguments.Get(0).(models.SearchFilters)
+		assert.EqualValues(t, test.expectedStatuses, filters.Status)
+	}
+}
+
+func TestHandler_SetTodoStatus(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo := repository.NewMemoryRepository()
+
+	note := models.NewNote(nil, "book flights")
+	note.Status = models.StatusOpen
+	assert.Nil(t, repo.WriteNote(ctx, note))
+
+	h := NewHandlerBuilder(repo).WithNowSupplier(func() time.Time { return now }).Build()
+	args := createAppContext(map[string]string{}, []string{note.ID})
+
+	assert.Nil(t, h.CancelTodo(args))
+	found, _ := repo.LookupNote(ctx, note.ID)
+	assert.EqualValues(t, models.StatusCancelled, found.Status)
+	assert.True(t, found.CompletedAt.Equal(now))
+
+	assert.Nil(t, h.ReopenTodo(args))
+	found, _ = repo.LookupNote(ctx, note.ID)
+	assert.EqualValues(t, models.StatusOpen, found.Status)
+	assert.True(t, found.CompletedAt.IsZero())
+
+	assert.EqualValues(t, errNoteNotFound, h.ReopenTodo(createAppContext(map[string]string{}, []string{uuid.NewV4().String()})))
+}
+
+func TestHandler_PinNote(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+
+	note := models.NewNote(nil, "git rebase -i")
+	assert.Nil(t, repo.WriteNote(ctx, note))
+
+	h := NewHandlerBuilder(repo).Build()
+	args := createAppContext(map[string]string{}, []string{note.ID})
+
+	assert.Nil(t, h.PinNote(args))
+	found, _ := repo.LookupNote(ctx, note.ID)
+	assert.True(t, found.Pinned)
+
+	assert.Nil(t, h.UnpinNote(args))
+	found, _ = repo.LookupNote(ctx, note.ID)
+	assert.False(t, found.Pinned)
+
+	assert.EqualValues(t, errNoteNotFound, h.PinNote(createAppContext(map[string]string{}, []string{uuid.NewV4().String()})))
+}
+
+func TestHandler_ListPins(t *testing.T) {
+	r := repository.NewMockRepository()
+	r.On("SearchNotesFunc", mock.Anything).Return(nil, nil)
+
+	h := NewHandlerBuilder(&r).Build()
+	assert.Nil(t, h.ListPins(createAppContext(map[string]string{"tags": "git"}, []string{})))
+
+	filters := r.Calls[0].Arguments.Get(0).(models.SearchFilters)
+	assert.True(t, filters.Pinned)
+	assert.EqualValues(t, []string{"git"}, filters.Tags)
+}
+
+func TestHandler_WriteNote_Template(t *testing.T) {
+	directory, err := ioutil.TempDir("", "sf-templates")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+
+	text := "---\ntitle: Standup {{date}}\ntags: Work\n---\n\n{{prompt \"Ticket?\"}}\nYesterday:\n{{yesterday_notes}}\n"
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(directory, "standup.md"), []byte(text), 0644))
+
+	now := time.Date(2019, 12, 11, 9, 0, 0, 0, time.Local)
+	repo := repository.NewMemoryRepository()
+	yesterday := models.NewNote(nil, "fixed the login bug")
+	yesterday.Timestamp = now.AddDate(0, 0, -1)
+	assert.Nil(t, repo.WriteNote(context.Background(), yesterday))
+
+	input := NewMockInput()
+	input.On("GetString").Return("SF-42").Once()
+
+	// The editor is given the rendered template, and the note is written as it's saved.
+	expected := "---\ntitle: Standup 2019-12-11\ntags: work, standup\n---\n\nSF-42\nYesterday:\n- fixed the login bug\n\nand reviews\n"
+	editor := NewMockEditor()
+	editor.On("Edit", expected).Return(strings.Replace(expected, "SF-42", "SF-43", 1), nil)
+
+	h := NewHandlerBuilder(repo).
+		WithNowSupplier(func() time.Time { return now }).
+		WithUserInputController(input).
+		WithEditor(editor).
+		WithTemplatesPath(directory).
+		Build()
+
+	context := createAppContext(map[string]string{"template": "standup", "tags": "standup"}, []string{"and", "reviews"})
+	assert.Nil(t, h.WriteNote(context))
+	editor.AssertExpectations(t)
+
+	notes, err := repo.SearchNotes(h.ctx, models.SearchFilters{Tags: []string{"standup"}})
+	assert.Nil(t, err)
+	assert.Len(t, notes, 1)
+	assert.EqualValues(t, "Standup 2019-12-11", notes[0].Title)
+	assert.EqualValues(t, []string{"work", "standup"}, notes[0].Tags)
+	assert.EqualValues(t, "SF-43\nYesterday:\n- fixed the login bug\n\nand reviews", notes[0].Content)
+
+	missing := createAppContext(map[string]string{"template": "missing"}, []string{})
+	assert.EqualValues(t, templates.ErrTemplateNotFound, h.WriteNote(missing))
+}
+
+func TestHandler_Daily(t *testing.T) {
+	now := time.Date(2019, 12, 11, 9, 30, 0, 0, time.Local)
+	repo := repository.NewMemoryRepository()
+
+	editor := NewMockEditor()
+	h := NewHandlerBuilder(repo).
+		WithNowSupplier(func() time.Time { return now }).
+		WithEditor(editor).
+		Build()
+
+	// The first entry creates the day's note, and later entries are appended to it.
+	assert.Nil(t, h.Daily(createAppContext(map[string]string{}, []string{"standup", "went", "long"})))
+	now = now.Add(time.Hour)
+	assert.Nil(t, h.Daily(createAppContext(map[string]string{}, []string{"reviewed", "#infra"})))
+
+	note, err := h.findDailyNote(now)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "09:30 standup went long\n10:30 reviewed #infra", note.Content)
+	assert.EqualValues(t, "Wednesday, December 11 2019", note.Title)
+	assert.EqualValues(t, []string{"infra"}, note.Tags)
+
+	// Without anything to append, the note is opened in the editor.
+	editor.On("Edit", note.Content+"\n").Return(note.Content+"\n11:00 lunch\n", nil).Once()
+	assert.Nil(t, h.Daily(createAppContext(map[string]string{}, []string{})))
+	editor.AssertExpectations(t)
+
+	note, err = h.findDailyNote(now)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "09:30 standup went long\n10:30 reviewed #infra\n11:00 lunch", note.Content)
+
+	// Each day has its own note.
+	tomorrow, err := h.findDailyNote(now.AddDate(0, 0, 1))
+	assert.Nil(t, err)
+	assert.Nil(t, tomorrow)
+
+	notes, err := repo.SearchNotes(h.ctx, models.SearchFilters{})
+	assert.Nil(t, err)
+	assert.Len(t, notes, 1)
+
+	assert.Nil(t, h.Daily(createAppContext(map[string]string{"date": "2019-12-11"}, []string{})))
+	assert.EqualValues(t, errInvalidDate, h.Daily(createAppContext(map[string]string{"date": "last week"}, []string{})))
+	assert.EqualValues(t, errDailyViewOnly, h.Daily(createAppContext(map[string]string{"date": "yesterday"}, []string{"late", "entry"})))
+}
+
+func TestHandler_Calendar(t *testing.T) {
+	now := time.Date(2019, 12, 11, 9, 30, 0, 0, time.Local)
+
+	r := repository.NewMockRepository()
+	r.On("SearchNotesFunc", mock.Anything).Return(nil, nil)
+
+	h := NewHandlerBuilder(&r).WithNowSupplier(func() time.Time { return now }).Build()
+	assert.Nil(t, h.Calendar(createAppContext(map[string]string{}, []string{"2020-02"})))
+
+	filters := r.Calls[0].Arguments.Get(0).(models.SearchFilters)
+	assert.EqualValues(t, time.Date(2020, 2, 1, 0, 0, 0, 0, time.Local), filters.DateRange.From)
+	assert.EqualValues(t, time.Date(2020, 2, 29, 23, 59, 59, 0, time.Local), filters.DateRange.To)
+
+	assert.EqualValues(t, errInvalidMonth, h.Calendar(createAppContext(map[string]string{}, []string{"february"})))
+}
+
+func TestHandler_Report(t *testing.T) {
+	r := repository.NewMockRepository()
+	h := NewHandlerBuilder(&r).Build()
+
+	assert.EqualValues(t, errReportsUnsupported, h.Report(createAppContext(map[string]string{"since": "7d"}, []string{})))
+}
+
+func TestHandler_Digest(t *testing.T) {
+	directory, err := ioutil.TempDir("", "sf-templates")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+
+	h := NewHandlerBuilder(repository.NewMemoryRepository()).WithTemplatesPath(directory).Build()
+
+	assert.Nil(t, h.Digest(createAppContext(map[string]string{"range": "this-week", "group": "tag"}, []string{})))
+	assert.NotNil(t, h.Digest(createAppContext(map[string]string{"range": "fortnight"}, []string{})))
+	assert.NotNil(t, h.Digest(createAppContext(map[string]string{"group": "week"}, []string{})))
+	assert.EqualValues(t, templates.ErrInvalidDigestFormat, h.Digest(createAppContext(map[string]string{"format": "pdf"}, []string{})))
+}
+
+func TestHandler_Publish(t *testing.T) {
+	directory, err := ioutil.TempDir("", "sf-site")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+
+	repo := repository.NewMemoryRepository()
+	public := models.NewNote([]string{"work"}, "shipped it")
+	private := models.NewNote([]string{"journal/health"}, "ran 5k")
+	assert.Nil(t, repo.WriteNote(context.Background(), public))
+	assert.Nil(t, repo.WriteNote(context.Background(), private))
+
+	h := NewHandlerBuilder(repo).WithPrivateTags([]string{"Journal"}).Build()
+
+	assert.EqualValues(t, errMissingOut, h.Publish(createAppContext(map[string]string{}, []string{})))
+	assert.Nil(t, h.Publish(createAppContext(map[string]string{"out": directory}, []string{})))
+
+	_, err = os.Stat(filepath.Join(directory, "notes", public.ID+".html"))
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(directory, "notes", private.ID+".html"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestHandler_Completions(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	now := time.Now()
+
+	for i := 0; i < completionNoteLimit+5; i++ {
+		note := models.NewNote([]string{"work", "work/infra"}, fmt.Sprintf("note %d\nmore content", i))
+		note.Timestamp = now.Add(time.Duration(i) * time.Minute)
+		assert.Nil(t, repo.WriteNote(context.Background(), note))
+	}
+
+	h := NewHandlerBuilder(repo).Build()
+
+	var tags bytes.Buffer
+	assert.Nil(t, h.writeTagCompletions(&tags, "bash"))
+	assert.EqualValues(t, "work\nwork/infra\n", tags.String())
+
+	// The latest notes come first, described by their first line where shells show descriptions.
+	var ids bytes.Buffer
+	assert.Nil(t, h.writeNoteIdCompletions(&ids, "zsh"))
+	lines := strings.Split(strings.TrimSpace(ids.String()), "\n")
+	assert.Len(t, lines, completionNoteLimit)
+	assert.True(t, strings.HasSuffix(lines[0], fmt.Sprintf(":note %d", completionNoteLimit+4)))
+
+	var fish bytes.Buffer
+	writeCompletion(&fish, "fish", "work", "25 notes")
+	writeCompletion(&fish, "zsh", "a:b", "1 notes")
+	assert.EqualValues(t, "work\t25 notes\na\\:b:1 notes\n", fish.String())
+
+	assert.EqualValues(t, errInvalidShell, h.Completion(createAppContext(map[string]string{}, []string{"powershell"})))
+}
+
+func TestHandler_SearchNotes_Fuzzy(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	assert.Nil(t, repo.WriteNote(context.Background(), models.NewNote(nil, "Restarted the Kubernetes deployment")))
+
+	h := NewHandlerBuilder(repo).Build()
+	assert.Nil(t, h.SearchNotes(createAppContext(map[string]string{"fuzzy": "kubernets"}, []string{})))
+}
+
+// Records the notes it's asked to print, in order.
+type recordingPrinter struct {
+	output.Printer
+	contents []string
+}
+
+func (printer *recordingPrinter) PrintNote(note *models.Note, options output.Options) {
+	printer.contents = append(printer.contents, note.Content)
+}
+
+func (printer *recordingPrinter) PrintNoteCount(int) {}
+
+func TestHandler_SearchNotes_FuzzyPinnedFirst(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+
+	exact := models.NewNote(nil, "Restarted the kubernetes deployment")
+	pinned := models.NewNote(nil, "Kubernets upgrade checklist")
+	assert.Nil(t, repo.WriteNote(ctx, exact))
+	assert.Nil(t, repo.WriteNote(ctx, pinned))
+	assert.Nil(t, repo.PinNote(ctx, pinned.ID, true))
+
+	printer := &recordingPrinter{}
+	h := NewHandlerBuilder(repo).Build()
+	h.printer = printer
+
+	assert.Nil(t, h.SearchNotes(createAppContext(map[string]string{"fuzzy": "kubernetes"}, []string{})))
+	assert.EqualValues(t, []string{pinned.Content, exact.Content}, printer.contents)
+}
+
+func TestHandler_SearchNotes_Regex(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	assert.Nil(t, repo.WriteNote(context.Background(), models.NewNote(nil, "TODO(alice): rotate keys")))
+
+	h := NewHandlerBuilder(repo).Build()
+	assert.Nil(t, h.SearchNotes(createAppContext(map[string]string{"regex": `TODO\(\w+\)`}, []string{})))
+	assert.NotNil(t, h.SearchNotes(createAppContext(map[string]string{"regex": "("}, []string{})))
+}
@@ -0,0 +1,63 @@
+package command
+
+import (
+	"fmt"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/ricanontherun/short-form/repository"
+	"github.com/urfave/cli/v2"
+)
+
+// Write a new open task.
+func (handler handler) AddTodo(ctx *cli.Context) error {
+	return handler.writeNoteFromInput(ctx, models.StatusOpen)
+}
+
+// List tasks, only the open ones unless told otherwise.
+func (handler handler) ListTodos(ctx *cli.Context) error {
+	filters, err := getSearchFiltersFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool(flagAll) {
+		filters.Status = models.Statuses
+	} else if len(filters.Status) == 0 {
+		filters.Status = []models.Status{models.StatusOpen}
+	}
+
+	// Tasks are referred to by ID, so always show it.
+	options := getPrintOptionsFromContext(ctx)
+	options.OneLine = true
+	options.Detailed = true
+
+	return handler.searchAndPrintNotes(filters, options)
+}
+
+// Reopen a task which was done or cancelled.
+func (handler handler) ReopenTodo(ctx *cli.Context) error {
+	return handler.setNoteStatus(ctx, models.StatusOpen)
+}
+
+// Cancel a task which won't be done.
+func (handler handler) CancelTodo(ctx *cli.Context) error {
+	return handler.setNoteStatus(ctx, models.StatusCancelled)
+}
+
+// Set the status of the note given on the command line.
+func (handler handler) setNoteStatus(ctx *cli.Context, status models.Status) error {
+	noteId, err := getNoteIdFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := handler.repository.SetNoteStatus(handler.ctx, noteId, status, handler.nowSupplyingFn()); err != nil {
+		if err == repository.ErrNoteNotFound {
+			return errNoteNotFound
+		}
+
+		return err
+	}
+
+	fmt.Println("ok")
+	return nil
+}
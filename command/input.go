@@ -20,5 +20,5 @@ func NewUserInputController() UserInputController {
 func (input userInput) GetString() string {
 	reader := bufio.NewReader(os.Stdin)
 	text, _ := reader.ReadString('\n')
-	return strings.TrimSpace(strings.ToLower(text))
+	return strings.TrimSpace(text)
 }
@@ -15,28 +15,35 @@ import (
 type parsedInput struct {
 	content string
 	tags    []string
+	title   string
+	meta    map[string]string
+
+	// When the note is due, see models.ParseDue.
+	due string
 }
 
 func getPrintOptionsFromContext(ctx *cli.Context) output.Options {
 	return output.Options{
 		SearchContent: ctx.String(flagContent),
+		SearchRegex:   ctx.String(flagRegex),
 		Detailed:      ctx.Bool(flagDetailed),
 		Pretty:        ctx.Bool(flagPretty),
 		SearchTags:    getTagsFromContext(ctx),
+		OneLine:       ctx.Bool(flagOneLine),
 	}
 }
 
 // Prompt the user for input.
-// Returns the trimmed, lowercase input.
+// Returns the trimmed input.
 func (handler handler) promptUser(message string) string {
 	fmt.Print(message)
-	return strings.TrimSpace(strings.ToLower(handler.inputController.GetString()))
+	return strings.TrimSpace(handler.inputController.GetString())
 }
 
 // Prompt the user with a confirmation message.
 // Returns whether the user answered 'y' or 'yes'
 func (handler handler) makeUserConfirmAction(message string) bool {
-	return utils.SliceContainsElement(handler.promptUser(message+" [y/n]: "), []string{
+	return utils.SliceContainsElement(strings.ToLower(handler.promptUser(message+" [y/n]: ")), []string{
 		"yes",
 		"y",
 	})
@@ -51,10 +58,19 @@ func getContentFromInput(ctx *cli.Context) (*parsedInput, error) {
 	var content string
 
 	args := ctx.Args().Slice()
+	due := strings.TrimSpace(ctx.String(flagDue))
+
+	// Flags only take one word, so "--due tomorrow 9am" leaves the time at the start of the content.
+	if len(due) > 0 && len(args) > 1 && !models.HasClock(due) && models.IsClock(args[0]) {
+		due += " " + args[0]
+		args = args[1:]
+	}
+
 	if len(args) != 0 {
 		fmt.Println("args: " + strings.Join(args, " "))
 		content = strings.Join(args, " ")
-	} else if stdinStat.Mode()&os.ModeCharDevice != 0 || stdinStat.Size() != 0 {
+	} else if len(ctx.String(flagTemplate)) == 0 && (stdinStat.Mode()&os.ModeCharDevice != 0 || stdinStat.Size() != 0) {
+		// Notes written from a template are written in the editor instead.
 		stdinReader := bufio.NewReader(os.Stdin)
 		var stdinBuilder strings.Builder
 
@@ -73,9 +89,17 @@ func getContentFromInput(ctx *cli.Context) (*parsedInput, error) {
 		content = strings.TrimSuffix(stdinBuilder.String(), "\n")
 	}
 
+	meta, err := models.ParseMeta(ctx.StringSlice(flagMeta))
+	if err != nil {
+		return nil, err
+	}
+
 	return &parsedInput{
 		content: content,
 		tags:    getTagsFromContext(ctx),
+		title:   strings.TrimSpace(ctx.String(flagTitle)),
+		meta:    meta,
+		due:     due,
 	}, nil
 }
 
@@ -88,19 +112,51 @@ func cleanTagsFromString(tagString string) []string {
 	tags := utils.NewSet()
 
 	for _, tag := range strings.Split(tagString, ",") {
-		trimmed := strings.TrimSpace(tag)
+		normalized := models.NormalizeTag(tag)
 
-		if len(trimmed) > 0 {
-			tags.Add(trimmed)
+		if len(normalized) > 0 {
+			tags.Add(normalized)
 		}
 	}
 
 	return tags.Entries()
 }
 
-func getSearchFiltersFromContext(c *cli.Context) models.SearchFilters {
-	return models.SearchFilters{
+func getSearchFiltersFromContext(c *cli.Context) (models.SearchFilters, error) {
+	filters := models.SearchFilters{
 		Tags:    getTagsFromContext(c),
+		TagTree: c.Bool(flagTagTree),
 		Content: strings.TrimSpace(c.String(flagContent)),
+		Fuzzy:   strings.TrimSpace(c.String(flagFuzzy)),
+		Regex:   c.String(flagRegex),
+		Title:   strings.TrimSpace(c.String(flagTitle)),
+		Pinned:  c.Bool(flagPinned),
+	}
+
+	// Checked here, since a pattern which doesn't compile would match nothing rather than fail.
+	if len(filters.Regex) > 0 {
+		if _, err := utils.CompileRegexp(filters.Regex); err != nil {
+			return filters, fmt.Errorf("invalid regex: %s", err.Error())
+		}
+	}
+
+	for _, expression := range c.StringSlice(flagMeta) {
+		filter, err := models.ParseMetaFilter(expression)
+		if err != nil {
+			return filters, err
+		}
+
+		filters.Meta = append(filters.Meta, filter)
 	}
+
+	for _, expression := range c.StringSlice(flagStatus) {
+		status, err := models.ParseStatus(expression)
+		if err != nil {
+			return filters, err
+		}
+
+		filters.Status = append(filters.Status, status)
+	}
+
+	return filters, nil
 }
@@ -3,5 +3,8 @@ package conf
 var (
 	shortFormDirectory           = ".sf"
 	shortFormDefaultDatabasePath = shortFormDirectory + "/data/data.db"
+	shortFormDefaultFilesPath    = shortFormDirectory + "/notes"
+	shortFormDefaultTemplatesDir = shortFormDirectory + "/templates"
+	shortFormDefaultStorage      = "sqlite"
 	shortFormConfigurationPath   = shortFormDirectory + "/config.json"
 )
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/ricanontherun/short-form/database"
 	"github.com/ricanontherun/short-form/logging"
 	"github.com/ricanontherun/short-form/utils"
 	"io/ioutil"
@@ -13,14 +14,46 @@ import (
 )
 
 type userConfig struct {
+	// Which storage backend notes are kept in, e.g sqlite, files or memory.
+	Storage string `json:"storage"`
+
 	DatabasePath string `json:"database_path"`
 
+	// Directory notes are written to by the files backend.
+	FilesPath string `json:"files_path"`
+
+	// How connections to the database are opened.
+	Connection database.Options `json:"connection"`
+
+	// Directory note templates are read from, see the templates package.
+	TemplatesPath string `json:"templates_path"`
+
+	// Tags which are replaced by another when notes are written or searched, e.g k8s: kubernetes.
+	TagAliases map[string]string `json:"tag_aliases"`
+
+	// Whether #tags are removed from note content once they've been added to the note's tags.
+	StripHashtags bool `json:"strip_hashtags"`
+
+	// Notes with these tags, or tags beneath them, are left out of published sites.
+	PrivateTags []string `json:"private_tags"`
+
 	user *user.User
 }
 
 type Config interface {
+	GetStorage() string
+	SetStorage(storage string) error
+	GetFilesPath() string
+	SetFilesPath(path string) error
 	GetDatabasePath() string
 	SetDatabasePath(path string) error
+	GetConnectionOptions() database.Options
+	GetTemplatesPath() string
+	GetTagAliases() map[string]string
+	SetTagAlias(alias string, tag string) error
+	RemoveTagAlias(alias string) error
+	GetStripHashtags() bool
+	GetPrivateTags() []string
 	Save() error
 }
 
@@ -37,10 +70,90 @@ func (config *userConfig) Save() error {
 	return nil
 }
 
+func (config *userConfig) GetStorage() string {
+	if len(config.Storage) == 0 {
+		return shortFormDefaultStorage
+	}
+
+	return config.Storage
+}
+
+func (config *userConfig) SetStorage(storage string) error {
+	if len(storage) == 0 {
+		return errors.New("cannot set storage, empty string")
+	}
+
+	config.Storage = storage
+	return nil
+}
+
+func (config *userConfig) GetFilesPath() string {
+	if len(config.FilesPath) == 0 {
+		return path.Join(config.user.HomeDir, shortFormDefaultFilesPath)
+	}
+
+	return config.FilesPath
+}
+
+func (config *userConfig) SetFilesPath(path string) error {
+	if len(path) == 0 {
+		return errors.New("cannot set files path, empty string")
+	}
+
+	config.FilesPath = path
+	return nil
+}
+
 func (config *userConfig) GetDatabasePath() string {
 	return config.DatabasePath
 }
 
+func (config *userConfig) GetConnectionOptions() database.Options {
+	return config.Connection
+}
+
+func (config *userConfig) GetTemplatesPath() string {
+	if len(config.TemplatesPath) == 0 {
+		return path.Join(config.user.HomeDir, shortFormDefaultTemplatesDir)
+	}
+
+	return config.TemplatesPath
+}
+
+func (config *userConfig) GetTagAliases() map[string]string {
+	return config.TagAliases
+}
+
+func (config *userConfig) SetTagAlias(alias string, tag string) error {
+	if len(alias) == 0 || len(tag) == 0 {
+		return errors.New("cannot set tag alias, empty string")
+	}
+
+	if config.TagAliases == nil {
+		config.TagAliases = make(map[string]string)
+	}
+
+	config.TagAliases[alias] = tag
+	return nil
+}
+
+func (config *userConfig) RemoveTagAlias(alias string) error {
+	if _, exists := config.TagAliases[alias]; !exists {
+		return fmt.Errorf("no alias for tag '%s'", alias)
+	}
+
+	delete(config.TagAliases, alias)
+	return nil
+}
+
+func (config *userConfig) GetStripHashtags() bool {
+	return config.StripHashtags
+}
+
+func (config *userConfig) GetPrivateTags() []string {
+	return config.PrivateTags
+}
+
 func (config *userConfig) SetDatabasePath(path string) error {
 	// Validate the path as being legit.
 	// If the file at path is non-empty ... should we warn the user?
@@ -56,8 +169,12 @@ func (config *userConfig) SetDatabasePath(path string) error {
 
 func newUserConfig(user *user.User) Config {
 	return &userConfig{
-		DatabasePath: path.Join(user.HomeDir, shortFormDefaultDatabasePath),
-		user:         user,
+		Storage:       shortFormDefaultStorage,
+		DatabasePath:  path.Join(user.HomeDir, shortFormDefaultDatabasePath),
+		FilesPath:     path.Join(user.HomeDir, shortFormDefaultFilesPath),
+		TemplatesPath: path.Join(user.HomeDir, shortFormDefaultTemplatesDir),
+		Connection:    database.DefaultOptions(),
+		user:          user,
 	}
 }
 
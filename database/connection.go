@@ -2,16 +2,51 @@ package database
 
 import (
 	"database/sql"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
+	"github.com/ricanontherun/short-form/utils"
+	"strings"
 )
 
+// The sqlite3 driver, with the functions notes are searched with registered on every connection.
+const driverName = "sqlite3_short_form"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			// SQLite implements "text REGEXP pattern" as regexp(pattern, text).
+			if err := conn.RegisterFunc("regexp", matchRegexp, true); err != nil {
+				return err
+			}
+
+			return conn.RegisterFunc("word_count", countWords, true)
+		},
+	})
+}
+
+// Match text against a Go regular expression, see regexp/syntax.
+func matchRegexp(pattern string, text string) (bool, error) {
+	compiled, err := utils.CompileRegexp(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	return compiled.MatchString(text), nil
+}
+
+// Count the words of text, separated by any amount of whitespace.
+func countWords(text string) int {
+	return len(strings.Fields(text))
+}
+
 // NewDatabaseConnection creates a new database connection.
-func NewDatabaseConnection(path string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", path)
+func NewDatabaseConnection(path string, options Options) (*sql.DB, error) {
+	db, err := sql.Open(driverName, options.dataSourceName(path))
 
 	if err != nil {
 		return nil, err
 	}
 
+	db.SetMaxOpenConns(options.withDefaults().MaxOpenConnections)
+
 	return db, nil
 }
@@ -7,35 +7,36 @@ import (
 	"sync"
 )
 
-var once sync.Once
-
 type postInitFunc func(db *sql.DB) error
 
 type Database interface {
 	SetPostInit(initFunc postInitFunc)
 	GetConnection() *sql.DB
+	GetPath() string
 	Close()
 }
 
 type database struct {
 	path     string
+	options  Options
 	database *sql.DB
 	postInit func(*sql.DB) error
+	once     sync.Once
 }
 
-func NewDatabase(path string) Database {
-	return &database{path, nil, nil}
+func NewDatabase(path string, options Options) Database {
+	return &database{path: path, options: options}
 }
 
 func (database *database) GetConnection() *sql.DB {
-	once.Do(func() {
+	database.once.Do(func() {
 		if exists, err := utils.EnsureFilePath(database.path); err != nil {
 			panic(err)
 		} else if !exists {
 			log.Println("created new database file " + database.path)
 		}
 
-		db, err := NewDatabaseConnection(database.path)
+		db, err := NewDatabaseConnection(database.path, database.options)
 		if err != nil {
 			panic(err)
 		}
@@ -52,6 +53,11 @@ func (database *database) GetConnection() *sql.DB {
 	return database.database
 }
 
+// GetPath returns the path of the database file on disk.
+func (database *database) GetPath() string {
+	return database.path
+}
+
 func (database *database) SetPostInit(call postInitFunc) {
 	database.postInit = call
 }
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultJournalMode        = "WAL"
+	defaultBusyTimeout        = 5000
+	defaultSynchronous        = "NORMAL"
+	defaultMaxOpenConnections = 4
+)
+
+// Options control how connections to the database are opened.
+// Zero values are replaced with sensible defaults.
+type Options struct {
+	// SQLite journal mode, e.g WAL, DELETE or TRUNCATE.
+	JournalMode string `json:"journal_mode"`
+
+	// How long (in milliseconds) to wait on a locked database before giving up.
+	BusyTimeout int `json:"busy_timeout"`
+
+	// SQLite synchronous level, e.g OFF, NORMAL, FULL or EXTRA.
+	Synchronous string `json:"synchronous"`
+
+	// Upper limit on the number of open connections.
+	MaxOpenConnections int `json:"max_open_connections"`
+}
+
+// DefaultOptions returns the options used when none are configured.
+func DefaultOptions() Options {
+	return Options{}.withDefaults()
+}
+
+func (options Options) withDefaults() Options {
+	if len(options.JournalMode) == 0 {
+		options.JournalMode = defaultJournalMode
+	}
+
+	if options.BusyTimeout <= 0 {
+		options.BusyTimeout = defaultBusyTimeout
+	}
+
+	if len(options.Synchronous) == 0 {
+		options.Synchronous = defaultSynchronous
+	}
+
+	if options.MaxOpenConnections <= 0 {
+		options.MaxOpenConnections = defaultMaxOpenConnections
+	}
+
+	return options
+}
+
+// dataSourceName builds the driver connection string for a database file.
+func (options Options) dataSourceName(path string) string {
+	options = options.withDefaults()
+
+	params := url.Values{}
+	params.Set("_journal_mode", strings.ToUpper(options.JournalMode))
+	params.Set("_busy_timeout", strconv.Itoa(options.BusyTimeout))
+	params.Set("_synchronous", strings.ToUpper(options.Synchronous))
+
+	// Take the write lock when a transaction begins, rather than upgrading to it
+	// part way through, so the busy timeout applies instead of failing immediately.
+	params.Set("_txlock", "immediate")
+
+	return path + "?" + params.Encode()
+}
@@ -16,6 +16,7 @@ require (
 	github.com/motemen/gore v0.4.1 // indirect
 	github.com/peterh/liner v1.1.0 // indirect
 	github.com/prometheus/client_golang v1.3.0 // indirect
+	github.com/russross/blackfriday/v2 v2.0.1
 	github.com/satori/go.uuid v1.2.0
 	github.com/stretchr/testify v1.4.0
 	github.com/urfave/cli v1.22.2
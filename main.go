@@ -1,17 +1,22 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/ricanontherun/short-form/command"
 	"github.com/ricanontherun/short-form/conf"
-	"github.com/ricanontherun/short-form/database"
+	"github.com/ricanontherun/short-form/models"
 	"github.com/ricanontherun/short-form/repository"
 	"github.com/urfave/cli/v2"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 )
 
 var (
@@ -29,17 +34,38 @@ var (
 		Value:   false,
 	}
 
+	ephemeralFlag = &cli.BoolFlag{
+		Name:  "ephemeral",
+		Usage: "Use a throwaway in-memory journal, seeded with demo notes",
+		Value: false,
+	}
+
 	appVersion = "2.0.0"
 )
 
 var searchFlags = []cli.Flag{
 	tagFlag,
+	&cli.BoolFlag{
+		Name:  "tag-tree",
+		Usage: "Match tags beneath the searched tags too, e.g -t work matches work/infra",
+		Value: false,
+	},
 	&cli.StringFlag{
 		Name:    "content",
 		Usage:   "Search by note content",
 		Aliases: []string{"c"},
 		Value:   "",
 	},
+	&cli.StringFlag{
+		Name:  "regex",
+		Usage: "Search note content by regular expression, e.g 'TODO\\(\\w+\\)', use (?i) to ignore case",
+		Value: "",
+	},
+	&cli.StringFlag{
+		Name:  "fuzzy",
+		Usage: "Search note content allowing for typos, ranking the closest matches first",
+		Value: "",
+	},
 	&cli.StringFlag{
 		Name:    "age",
 		Usage:   "Search by age of note, e.g 2d for 2 days old",
@@ -52,6 +78,61 @@ var searchFlags = []cli.Flag{
 		Usage:   "Display detailed note information",
 		Value:   false,
 	},
+	&cli.StringFlag{
+		Name:  "title",
+		Usage: "Search by note title, or first line when a note has no title",
+		Value: "",
+	},
+	&cli.BoolFlag{
+		Name:    "oneline",
+		Aliases: []string{"o"},
+		Usage:   "Display one note per line, by title",
+		Value:   false,
+	},
+	&cli.StringSliceFlag{
+		Name:    "meta",
+		Aliases: []string{"m"},
+		Usage:   "Search by metadata, e.g project=apollo, priority>=2 or ticket to require the key",
+	},
+	&cli.BoolFlag{
+		Name:  "pinned",
+		Usage: "Only search pinned notes",
+		Value: false,
+	},
+	&cli.StringSliceFlag{
+		Name:  "status",
+		Usage: "Search tasks by status, open, done or cancelled, may be repeated",
+	},
+}
+
+var writeFlags = []cli.Flag{
+	tagFlag,
+	confirmFlag,
+	&cli.StringFlag{
+		Name:  "title",
+		Usage: "Title of the note, the first line of content is used by default",
+		Value: "",
+	},
+	&cli.StringSliceFlag{
+		Name:    "meta",
+		Aliases: []string{"m"},
+		Usage:   "Metadata as key=value, may be repeated",
+	},
+	&cli.StringFlag{
+		Name:  "due",
+		Usage: "When the note is due, e.g tomorrow 9am, friday, 2h or 2019-12-08 17:00",
+		Value: "",
+	},
+	&cli.StringSliceFlag{
+		Name:      "attach",
+		Usage:     "Store a file with the note, may be repeated",
+		TakesFile: true,
+	},
+	&cli.StringFlag{
+		Name:  "template",
+		Usage: "Write the note in your editor, starting from a template, see sf template",
+		Value: "",
+	},
 }
 
 func dd(message string) {
@@ -59,16 +140,98 @@ func dd(message string) {
 	os.Exit(1)
 }
 
-// Setup support for ctrl-c interrupt signals which are ignored whilst
-// waiting for user input by default.
-func setupSignalHandlers() {
+// How long a cancelled command has to finish before the process exits regardless.
+const interruptGracePeriod = 2 * time.Second
+
+// Setup support for ctrl-c interrupt signals. The returned context is cancelled on
+// the first signal, rolling back any in-flight database work. Reading user input
+// can't be interrupted, so the process exits if the command hasn't returned
+// within the grace period, or on a second signal.
+func setupSignalHandlers() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+
 	signalChan := make(chan os.Signal, 2)
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
 		<-signalChan
+		cancel()
+
+		select {
+		case <-signalChan:
+		case <-time.After(interruptGracePeriod):
+		}
+
 		os.Exit(1)
 	}()
+
+	return ctx
+}
+
+// Check if a global flag was given. Global flags which decide how storage is
+// opened are needed before the commands are built, so they're read ahead of
+// the cli parse. Like all global flags, they must precede the command name.
+// Accepts the same spellings cli does, e.g --ephemeral and --ephemeral=true.
+func hasGlobalFlag(args []string, flag *cli.BoolFlag) (bool, error) {
+	names := append([]string{flag.Name}, flag.Aliases...)
+
+	for _, arg := range args[1:] {
+		if !strings.HasPrefix(arg, "-") {
+			return false, nil
+		}
+
+		given, value := arg, ""
+		if separator := strings.Index(arg, "="); separator != -1 {
+			given, value = arg[:separator], arg[separator+1:]
+		}
+
+		for _, name := range names {
+			if given != "-"+name && given != "--"+name {
+				continue
+			}
+
+			if given == arg {
+				return true, nil
+			}
+
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return false, fmt.Errorf("invalid value %q for flag -%s", value, name)
+			}
+
+			return enabled, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Fill a repository with a few example notes, for trying sf out.
+func seedDemoNotes(ctx context.Context, repo repository.Repository) error {
+	now := time.Now()
+
+	demoNotes := []struct {
+		age     time.Duration
+		tags    []string
+		content string
+	}{
+		{26 * time.Hour, []string{"git", "cli"}, "git rebase: git rebase COMMIT"},
+		{25 * time.Hour, []string{"git"}, "git rebase (interactive): git rebase -i COMMIT"},
+		{3 * time.Hour, nil, "Hello, this is a note."},
+		{2 * time.Hour, []string{"music"}, "Listen to A Love Supreme again"},
+		{time.Hour, []string{"go", "cli"}, "go test -run TestName ./..."},
+	}
+
+	for _, demo := range demoNotes {
+		note := models.NewNote(demo.tags, demo.content)
+		note.Timestamp = now.Add(-demo.age)
+
+		if err := repo.WriteNote(ctx, note); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func main() {
@@ -77,39 +240,60 @@ func main() {
 		log.Fatalln(err)
 	}
 
-	db := database.NewDatabase(userConfig.GetDatabasePath())
+	ctx := setupSignalHandlers()
 
-	repo, err := repository.NewSqlRepository(db)
+	storage := userConfig.GetStorage()
+	ephemeral, err := hasGlobalFlag(os.Args, ephemeralFlag)
 	if err != nil {
-		log.Fatalf("Failed to open database: %s\n", err.Error())
+		log.Fatalln(err)
 	}
 
-	handler := command.NewHandlerBuilder(repo).Build()
+	if ephemeral {
+		storage = repository.BackendMemory
+	}
 
-	setupSignalHandlers()
+	repo, err := repository.OpenBackend(storage, userConfig)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %s\n", err.Error())
+	}
+
+	if ephemeral {
+		if err := seedDemoNotes(ctx, repo); err != nil {
+			log.Fatalf("Failed to seed demo notes: %s\n", err.Error())
+		}
+	}
+
+	handler := command.NewHandlerBuilder(repo).
+		WithContext(ctx).
+		WithTagAliases(userConfig.GetTagAliases()).
+		WithStripHashtags(userConfig.GetStripHashtags()).
+		WithTemplatesPath(userConfig.GetTemplatesPath()).
+		WithPrivateTags(userConfig.GetPrivateTags()).
+		Build()
 
 	app := cli.App{
 		Name:        "sf",
 		Usage:       "A command-line journal for bite sized thoughts",
 		Description: "short-form allows you to write, tag and search for short notes via the command line.",
 		Version:     appVersion,
+
+		EnableBashCompletion: true,
+
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:    "pretty",
 				Aliases: []string{"p"},
 				Value:   false,
 			},
+			ephemeralFlag,
 		},
 		Commands: []*cli.Command{
 			{
 				Name:    "write",
 				Aliases: []string{"w"},
 				Usage:   "Write a new note",
-				Flags: []cli.Flag{
-					tagFlag,
-					confirmFlag,
-				},
-				Action: handler.WriteNote,
+				Flags:   writeFlags,
+				Action:  handler.WriteNote,
 			},
 			{
 				Name:    "delete",
@@ -118,13 +302,15 @@ func main() {
 				Flags: []cli.Flag{
 					confirmFlag,
 				},
-				Action: handler.DeleteNote,
+				Action:       handler.DeleteNote,
+				BashComplete: handler.CompleteNoteIds,
 			},
 			{
-				Name:    "edit",
-				Aliases: []string{"e"},
-				Usage:   "Edit a note's content",
-				Action:  handler.EditNote,
+				Name:         "edit",
+				Aliases:      []string{"e"},
+				Usage:        "Edit a note's content, tags or title",
+				Action:       handler.EditNote,
+				BashComplete: handler.CompleteNoteIds,
 			},
 			{
 				Name:    "search",
@@ -188,6 +374,365 @@ func main() {
 							return handler.ConfigureDatabase(ctx, userConfig)
 						},
 					},
+					{
+						Name:    "storage",
+						Usage:   "Configure where notes are stored",
+						Aliases: []string{"s"},
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "backend",
+								Aliases:  []string{"b"},
+								Usage:    "Storage backend, one of: " + strings.Join(repository.Backends(), ", "),
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:    "path",
+								Aliases: []string{"p"},
+								Usage:   "Path to the database file (sqlite) or notes directory (files)",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return handler.ConfigureStorage(ctx, userConfig)
+						},
+					},
+				},
+			},
+			{
+				Name:  "tags",
+				Usage: "Manage tags",
+				Subcommands: []*cli.Command{
+					{
+						Name:    "list",
+						Aliases: []string{"ls"},
+						Usage:   "List tags as a tree, with how many notes have each",
+						Action:  handler.ListTags,
+					},
+					{
+						Name:      "rename",
+						Aliases:   []string{"mv"},
+						Usage:     "Rename a tag, and every tag beneath it",
+						ArgsUsage: "FROM TO",
+						Flags: []cli.Flag{
+							confirmFlag,
+						},
+						Action: handler.RenameTag,
+					},
+					{
+						Name:      "alias",
+						Usage:     "Replace a tag with another when notes are written or searched, or list aliases",
+						ArgsUsage: "[ALIAS TAG]",
+						Action: func(ctx *cli.Context) error {
+							return handler.AliasTag(ctx, userConfig)
+						},
+					},
+					{
+						Name:      "unalias",
+						Usage:     "Remove a tag alias",
+						ArgsUsage: "ALIAS",
+						Action: func(ctx *cli.Context) error {
+							return handler.UnaliasTag(ctx, userConfig)
+						},
+					},
+				},
+			},
+			{
+				Name:  "due",
+				Usage: "List overdue notes, and notes due soon",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "within",
+						Aliases: []string{"w"},
+						Usage:   "How far ahead to look for upcoming notes, e.g 2h, 3d or 1w",
+						Value:   "7d",
+					},
+				},
+				Action: handler.ListDue,
+			},
+			{
+				Name:         "done",
+				Usage:        "Mark a note complete",
+				ArgsUsage:    "ID",
+				Action:       handler.CompleteNote,
+				BashComplete: handler.CompleteNoteIds,
+			},
+			{
+				Name:  "report",
+				Usage: "Summarise recent notes by day, hour and tag",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "since",
+						Aliases: []string{"s"},
+						Usage:   "How far back to report on, e.g 7d, 2w or 24h",
+						Value:   "7d",
+					},
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Usage:   "Print the report as text, json or markdown",
+						Value:   "text",
+					},
+				},
+				Action: handler.Report,
+			},
+			{
+				Name:  "digest",
+				Usage: "Print the notes written over a period as Markdown or HTML, grouped by day or tag",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:    "range",
+						Aliases: []string{"r"},
+						Usage:   "The period to digest, e.g today, this-week, last-week, last-month or 3d",
+						Value:   "last-week",
+					},
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Usage:   "Print the digest as md or html, templates in the templates directory named digest.md or digest.html are used instead of the defaults",
+						Value:   "md",
+					},
+					&cli.StringFlag{
+						Name:    "group",
+						Aliases: []string{"g"},
+						Usage:   "Group notes by day, tag, or tag-day for tags then days",
+						Value:   "day",
+					},
+				}, searchFlags...),
+				Action: handler.Digest,
+			},
+			{
+				Name:  "publish",
+				Usage: "Write notes to a static HTML site, with an index by date, tag pages and search",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:  "out",
+						Usage: "Directory the site is written to, which must be empty or a site published before",
+					},
+				}, searchFlags...),
+				Action: handler.Publish,
+			},
+			{
+				Name:      "daily",
+				Usage:     "Append to today's daily note, or open it in your editor",
+				ArgsUsage: "[CONTENT]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "date",
+						Usage: "View the daily note of another day, e.g 2019-12-08 or yesterday",
+						Value: "",
+					},
+					&cli.BoolFlag{
+						Name:    "detailed",
+						Aliases: []string{"d"},
+						Usage:   "Display detailed note information",
+						Value:   false,
+					},
+				},
+				Action: handler.Daily,
+			},
+			{
+				Name:      "calendar",
+				Aliases:   []string{"cal"},
+				Usage:     "Show which days of a month have notes, and how many",
+				ArgsUsage: "[MONTH, e.g 2019-12]",
+				Action:    handler.Calendar,
+			},
+			{
+				Name:  "template",
+				Usage: "Manage note templates, see sf w --template",
+				Subcommands: []*cli.Command{
+					{
+						Name:    "list",
+						Aliases: []string{"ls"},
+						Usage:   "List templates",
+						Action:  handler.ListTemplates,
+					},
+					{
+						Name:      "show",
+						Usage:     "Print a template",
+						ArgsUsage: "NAME",
+						Action:    handler.ShowTemplate,
+					},
+					{
+						Name:      "new",
+						Usage:     "Write a new template in your editor",
+						ArgsUsage: "NAME",
+						Action:    handler.NewTemplate,
+					},
+				},
+			},
+			{
+				Name:         "pin",
+				Usage:        "Pin a note, so it's listed first in searches",
+				ArgsUsage:    "ID",
+				Action:       handler.PinNote,
+				BashComplete: handler.CompleteNoteIds,
+			},
+			{
+				Name:         "unpin",
+				Usage:        "Unpin a note",
+				ArgsUsage:    "ID",
+				Action:       handler.UnpinNote,
+				BashComplete: handler.CompleteNoteIds,
+			},
+			{
+				Name:   "pins",
+				Usage:  "List pinned notes",
+				Flags:  searchFlags,
+				Action: handler.ListPins,
+			},
+			{
+				Name:  "todo",
+				Usage: "Write and track tasks",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "add",
+						Usage:     "Write a new task",
+						ArgsUsage: "CONTENT",
+						Flags:     writeFlags,
+						Action:    handler.AddTodo,
+					},
+					{
+						Name:    "list",
+						Aliases: []string{"ls"},
+						Usage:   "List open tasks",
+						Flags: append([]cli.Flag{
+							&cli.BoolFlag{
+								Name:    "all",
+								Aliases: []string{"A"},
+								Usage:   "Include done and cancelled tasks",
+								Value:   false,
+							},
+						}, searchFlags...),
+						Action: handler.ListTodos,
+					},
+					{
+						Name:         "done",
+						Usage:        "Mark a task done",
+						ArgsUsage:    "ID",
+						Action:       handler.CompleteNote,
+						BashComplete: handler.CompleteNoteIds,
+					},
+					{
+						Name:         "reopen",
+						Usage:        "Reopen a done or cancelled task",
+						ArgsUsage:    "ID",
+						Action:       handler.ReopenTodo,
+						BashComplete: handler.CompleteNoteIds,
+					},
+					{
+						Name:         "cancel",
+						Usage:        "Cancel a task which won't be done",
+						ArgsUsage:    "ID",
+						Action:       handler.CancelTodo,
+						BashComplete: handler.CompleteNoteIds,
+					},
+				},
+			},
+			{
+				Name:  "remind",
+				Usage: "Print notes which are due, for use from cron or a shell prompt",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "within",
+						Aliases: []string{"w"},
+						Usage:   "Include notes due within this length of time, e.g 15m",
+						Value:   "0",
+					},
+				},
+				Action: handler.Remind,
+			},
+			{
+				Name:         "links",
+				Usage:        "List the notes a note links to with [[ID]]",
+				ArgsUsage:    "ID",
+				Action:       handler.Links,
+				BashComplete: handler.CompleteNoteIds,
+			},
+			{
+				Name:         "backlinks",
+				Usage:        "List the notes which link to a note",
+				ArgsUsage:    "ID",
+				Action:       handler.Backlinks,
+				BashComplete: handler.CompleteNoteIds,
+			},
+			{
+				Name:         "attachments",
+				Usage:        "List a note's attachments",
+				ArgsUsage:    "ID",
+				Action:       handler.ListAttachments,
+				BashComplete: handler.CompleteNoteIds,
+			},
+			{
+				Name:  "attachment",
+				Usage: "Manage a note's attachments",
+				Subcommands: []*cli.Command{
+					{
+						Name:         "add",
+						Usage:        "Attach files to a note",
+						ArgsUsage:    "ID FILE...",
+						Action:       handler.AddAttachments,
+						BashComplete: handler.CompleteNoteIds,
+					},
+					{
+						Name:      "get",
+						Usage:     "Write an attachment to a file",
+						ArgsUsage: "ID NAME",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "output",
+								Aliases: []string{"o"},
+								Usage:   "Path to write to, - for stdout. Defaults to the attachment's name",
+								Value:   "",
+							},
+						},
+						Action:       handler.GetAttachment,
+						BashComplete: handler.CompleteNoteIds,
+					},
+				},
+			},
+			{
+				Name:  "retag",
+				Usage: "Add tags to existing notes",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "from-content",
+						Usage: "Add the #tags written in each note's content",
+						Value: false,
+					},
+				},
+				Action: handler.RetagNotes,
+			},
+			{
+				Name:  "db",
+				Usage: "Database maintenance",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "check",
+						Usage: "Check the database for corruption and orphaned tags",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "fix",
+								Usage: "Remove orphaned tags",
+								Value: false,
+							},
+						},
+						Action: handler.CheckDatabase,
+					},
+					{
+						Name:   "vacuum",
+						Usage:  "Rebuild the database file, reclaiming unused space",
+						Action: handler.VacuumDatabase,
+					},
+					{
+						Name:   "optimize",
+						Usage:  "Refresh query planner statistics",
+						Action: handler.OptimizeDatabase,
+					},
+					{
+						Name:   "stats",
+						Usage:  "Display database statistics",
+						Action: handler.DatabaseStats,
+					},
 				},
 			},
 			{
@@ -199,10 +744,35 @@ func main() {
 					tagFlag,
 				},
 			},
+			{
+				Name:      "completion",
+				Usage:     "Print a shell completion script, e.g source <(sf completion bash)",
+				ArgsUsage: "bash|zsh|fish",
+				Action:    handler.Completion,
+			},
 		},
 	}
 
+	setCompletion(app.Commands, handler.Complete)
+
 	if err = app.Run(os.Args); err != nil {
+		// Errors such as a failed migration wrap the cancellation.
+		if errors.Is(err, context.Canceled) {
+			dd("cancelled")
+			return
+		}
+
 		dd(err.Error())
 	}
 }
+
+// Commands complete their flags, subcommands and tags, unless they complete something else such as note IDs.
+func setCompletion(commands []*cli.Command, complete cli.BashCompleteFunc) {
+	for _, command := range commands {
+		if command.BashComplete == nil {
+			command.BashComplete = complete
+		}
+
+		setCompletion(command.Subcommands, complete)
+	}
+}
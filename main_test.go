@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHasGlobalFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected bool
+		valid    bool
+	}{
+		{[]string{"sf", "--ephemeral", "w", "note"}, true, true},
+		{[]string{"sf", "-ephemeral", "w", "note"}, true, true},
+		{[]string{"sf", "--ephemeral=true", "w", "note"}, true, true},
+		{[]string{"sf", "-ephemeral=1", "w", "note"}, true, true},
+		{[]string{"sf", "--ephemeral=false", "w", "note"}, false, true},
+		{[]string{"sf", "w", "--ephemeral", "note"}, false, true},
+		{[]string{"sf", "--ephemerality", "w"}, false, true},
+		{[]string{"sf", "--ephemeral=yes", "w", "note"}, false, false},
+	}
+
+	for _, test := range tests {
+		ephemeral, err := hasGlobalFlag(test.args, ephemeralFlag)
+
+		if test.valid {
+			assert.Nil(t, err, test.args)
+			assert.EqualValues(t, test.expected, ephemeral, test.args)
+		} else {
+			assert.NotNil(t, err, test.args)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Attachment is a file stored alongside a note.
+type Attachment struct {
+	NoteID string
+
+	// The file's name, unique within a note.
+	Name string
+
+	// Size of the file, in bytes.
+	Size int64
+
+	// SHA-256 of the file's content, hex encoded.
+	Hash string
+
+	// The file's content. Left empty when attachments are listed.
+	Data []byte
+
+	Timestamp time.Time
+}
+
+// NewAttachment creates an attachment holding data.
+func NewAttachment(noteId string, name string, data []byte) Attachment {
+	hash := sha256.Sum256(data)
+
+	return Attachment{
+		NoteID:    noteId,
+		Name:      name,
+		Size:      int64(len(data)),
+		Hash:      hex.EncodeToString(hash[:]),
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+}
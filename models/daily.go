@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// DailyMetaKey marks a note as the daily note for a date, e.g daily=2019-12-08.
+const DailyMetaKey = "daily"
+
+// DailyDateFormat is how days are written, both in daily notes and on the command line.
+const DailyDateFormat = "2006-01-02"
+
+// DailyFilters finds the daily note for the day of a given time. Daily notes are
+// written on their day, see GetRangeToday.
+func DailyFilters(day time.Time) SearchFilters {
+	dateRange := GetRangeToday(day)
+
+	return SearchFilters{
+		DateRange: &dateRange,
+		Meta: []MetaFilter{{
+			Key:      DailyMetaKey,
+			Operator: MetaEquals,
+			Value:    day.Format(DailyDateFormat),
+		}},
+	}
+}
+
+// NewDailyNote creates the daily note for the day of a given time, written at that time.
+func NewDailyNote(now time.Time, content string) Note {
+	note := NewNote(nil, content)
+	note.Timestamp = now
+	note.Title = now.Format("Monday, January 2 2006")
+	note.Meta = map[string]string{DailyMetaKey: now.Format(DailyDateFormat)}
+
+	return note
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -26,3 +28,34 @@ func getRange(t time.Time) DateRange {
 		To:   rangeEnd,
 	}
 }
+
+// ParseDateRange reads a period relative to now: today, yesterday, this-week, last-week,
+// this-month, last-month, or a length of time such as 7d, see ParseOffset.
+// Weeks start on Monday.
+func ParseDateRange(expression string, now time.Time) (DateRange, error) {
+	today := getRange(now)
+	startOfWeek := today.From.AddDate(0, 0, -((int(now.Weekday()) + 6) % 7))
+	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	switch strings.ToLower(strings.TrimSpace(expression)) {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return GetRangeYesterday(now), nil
+	case "this-week":
+		return DateRange{From: startOfWeek, To: today.To}, nil
+	case "last-week":
+		return DateRange{From: startOfWeek.AddDate(0, 0, -7), To: getRange(startOfWeek.AddDate(0, 0, -1)).To}, nil
+	case "this-month":
+		return DateRange{From: startOfMonth, To: today.To}, nil
+	case "last-month":
+		return DateRange{From: startOfMonth.AddDate(0, -1, 0), To: getRange(startOfMonth.AddDate(0, 0, -1)).To}, nil
+	}
+
+	offset, err := ParseOffset(expression)
+	if err != nil {
+		return DateRange{}, fmt.Errorf("invalid range '%s', e.g last-week, this-month or 7d", expression)
+	}
+
+	return DateRange{From: now.Add(-offset), To: now}, nil
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// How notes in a digest are grouped.
+const (
+	DigestByDay        = "day"
+	DigestByTag        = "tag"
+	DigestByTagThenDay = "tag-day"
+)
+
+// Group name for notes without tags, when grouped by tag.
+const DigestUntagged = "untagged"
+
+// Digest is a readable summary of the notes written over a period.
+type Digest struct {
+	From time.Time
+	To   time.Time
+
+	NoteCount int
+
+	Groups []DigestGroup
+}
+
+// DigestGroup is a day or tag of a digest. Groups have either notes, or groups of their own.
+type DigestGroup struct {
+	Name string
+
+	// Whether the group is a day, rather than a tag.
+	Day bool
+
+	Notes  []*Note
+	Groups []DigestGroup
+}
+
+// BuildDigest groups notes, see DigestByDay. Notes are in the order they were written
+// within each group. Notes with several tags appear under each of them.
+func BuildDigest(dateRange DateRange, notes []*Note, grouping string) (*Digest, error) {
+	sorted := append([]*Note{}, notes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	digest := Digest{From: dateRange.From, To: dateRange.To, NoteCount: len(sorted)}
+
+	switch grouping {
+	case DigestByDay, "":
+		digest.Groups = groupByDay(sorted)
+	case DigestByTag:
+		digest.Groups = groupByTag(sorted)
+	case DigestByTagThenDay:
+		digest.Groups = groupByTag(sorted)
+		for i := range digest.Groups {
+			digest.Groups[i].Groups = groupByDay(digest.Groups[i].Notes)
+			digest.Groups[i].Notes = nil
+		}
+	default:
+		return nil, fmt.Errorf("invalid grouping '%s', expected day, tag or tag-day", grouping)
+	}
+
+	return &digest, nil
+}
+
+func groupByDay(notes []*Note) []DigestGroup {
+	var groups []DigestGroup
+
+	for _, note := range notes {
+		name := note.Timestamp.Format("Monday, January 2")
+
+		if len(groups) == 0 || groups[len(groups)-1].Name != name {
+			groups = append(groups, DigestGroup{Name: name, Day: true})
+		}
+
+		groups[len(groups)-1].Notes = append(groups[len(groups)-1].Notes, note)
+	}
+
+	return groups
+}
+
+// Group notes by tag, sorted by tag, with untagged notes last.
+func groupByTag(notes []*Note) []DigestGroup {
+	byTag := make(map[string][]*Note)
+	var untagged []*Note
+
+	for _, note := range notes {
+		if len(note.Tags) == 0 {
+			untagged = append(untagged, note)
+		}
+
+		for _, tag := range note.Tags {
+			byTag[tag] = append(byTag[tag], note)
+		}
+	}
+
+	tags := make([]string, 0, len(byTag))
+	for tag := range byTag {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	groups := make([]DigestGroup, 0, len(tags)+1)
+	for _, tag := range tags {
+		groups = append(groups, DigestGroup{Name: tag, Notes: byTag[tag]})
+	}
+
+	if len(untagged) > 0 {
+		groups = append(groups, DigestGroup{Name: DigestUntagged, Notes: untagged})
+	}
+
+	return groups
+}
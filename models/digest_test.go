@@ -0,0 +1,94 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseDateRange(t *testing.T) {
+	// A Wednesday.
+	now := time.Date(2019, 12, 11, 15, 30, 0, 0, time.Local)
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2019, month, day, 0, 0, 0, 0, time.Local)
+	}
+	endOf := func(month time.Month, d int) time.Time {
+		return time.Date(2019, month, d, 23, 59, 59, 0, time.Local)
+	}
+
+	tests := []struct {
+		expression string
+		expected   DateRange
+	}{
+		{"today", DateRange{From: day(12, 11), To: endOf(12, 11)}},
+		{"yesterday", DateRange{From: day(12, 10), To: endOf(12, 10)}},
+		{"this-week", DateRange{From: day(12, 9), To: endOf(12, 11)}},
+		{"Last-Week", DateRange{From: day(12, 2), To: endOf(12, 8)}},
+		{"this-month", DateRange{From: day(12, 1), To: endOf(12, 11)}},
+		{"last-month", DateRange{From: day(11, 1), To: endOf(11, 30)}},
+		{"3d", DateRange{From: now.AddDate(0, 0, -3), To: now}},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseDateRange(test.expression, now)
+		assert.Nil(t, err, test.expression)
+		assert.True(t, test.expected.From.Equal(actual.From), test.expression)
+		assert.True(t, test.expected.To.Equal(actual.To), test.expression)
+	}
+
+	// Weeks start on Monday, so on a Sunday this week began six days ago.
+	sunday := time.Date(2019, 12, 15, 9, 0, 0, 0, time.Local)
+	actual, err := ParseDateRange("this-week", sunday)
+	assert.Nil(t, err)
+	assert.True(t, day(12, 9).Equal(actual.From))
+
+	_, err = ParseDateRange("fortnight", now)
+	assert.NotNil(t, err)
+}
+
+func TestBuildDigest(t *testing.T) {
+	at := func(day int, hour int) time.Time {
+		return time.Date(2019, 12, day, hour, 0, 0, 0, time.Local)
+	}
+	note := func(timestamp time.Time, tags ...string) *Note {
+		note := NewNote(tags, "content")
+		note.Timestamp = timestamp
+		return &note
+	}
+
+	late := note(at(9, 18), "work")
+	early := note(at(9, 9), "work", "home")
+	untagged := note(at(10, 12))
+	notes := []*Note{late, untagged, early}
+	dateRange := DateRange{From: at(9, 0), To: at(10, 23)}
+
+	digest, err := BuildDigest(dateRange, notes, DigestByDay)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 3, digest.NoteCount)
+	assert.Len(t, digest.Groups, 2)
+	assert.EqualValues(t, "Monday, December 9", digest.Groups[0].Name)
+	assert.True(t, digest.Groups[0].Day)
+	assert.EqualValues(t, []*Note{early, late}, digest.Groups[0].Notes)
+	assert.EqualValues(t, []*Note{untagged}, digest.Groups[1].Notes)
+
+	// Notes appear under each of their tags, with untagged notes last.
+	digest, err = BuildDigest(dateRange, notes, DigestByTag)
+	assert.Nil(t, err)
+	assert.Len(t, digest.Groups, 3)
+	assert.EqualValues(t, "home", digest.Groups[0].Name)
+	assert.False(t, digest.Groups[0].Day)
+	assert.EqualValues(t, []*Note{early}, digest.Groups[0].Notes)
+	assert.EqualValues(t, "work", digest.Groups[1].Name)
+	assert.EqualValues(t, []*Note{early, late}, digest.Groups[1].Notes)
+	assert.EqualValues(t, DigestUntagged, digest.Groups[2].Name)
+
+	digest, err = BuildDigest(dateRange, notes, DigestByTagThenDay)
+	assert.Nil(t, err)
+	assert.Len(t, digest.Groups, 3)
+	assert.Empty(t, digest.Groups[1].Notes)
+	assert.Len(t, digest.Groups[1].Groups, 1)
+	assert.EqualValues(t, []*Note{early, late}, digest.Groups[1].Groups[0].Notes)
+
+	_, err = BuildDigest(dateRange, notes, "week")
+	assert.NotNil(t, err)
+}
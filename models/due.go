@@ -0,0 +1,193 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDueHour is the hour a note is due when only a day is given.
+const DefaultDueHour = 9
+
+var (
+	relativeDuePattern = regexp.MustCompile(`^(\d+)([mhdw])$`)
+	clockPattern       = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+
+	// The T between an ISO 8601 date and time, e.g 2019-12-08T17:00
+	isoSeparatorPattern = regexp.MustCompile(`(\d)T(\d)`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// ParseDue reads when a note is due, relative to now. It understands
+//
+//	a day:       today, tomorrow, friday, next friday, 2019-12-08
+//	a time:      9am, 9:30pm, 17:00, noon, midnight
+//	a day and a time, e.g tomorrow 9am or 2019-12-08 17:00
+//	an offset:   30m, 2h, 3d, 1w, optionally preceded by "in"
+//
+// A day without a time is due at DefaultDueHour. A time without a day is due
+// today, or tomorrow when that time has already passed.
+func ParseDue(expression string, now time.Time) (time.Time, error) {
+	invalid := fmt.Errorf("invalid due date '%s'", expression)
+
+	fields := strings.Fields(strings.ToLower(isoSeparatorPattern.ReplaceAllString(expression, "$1 $2")))
+	if len(fields) > 0 && (fields[0] == "in" || fields[0] == "next") {
+		fields = fields[1:]
+	}
+
+	if len(fields) == 0 || len(fields) > 2 {
+		return time.Time{}, invalid
+	}
+
+	if len(fields) == 1 {
+		if offset, err := ParseOffset(fields[0]); err == nil {
+			return now.Add(offset).Truncate(time.Minute), nil
+		}
+	}
+
+	day, dayErr := parseDueDay(fields[0], now)
+
+	if len(fields) == 1 {
+		if dayErr == nil {
+			return day.Add(DefaultDueHour * time.Hour), nil
+		}
+
+		// Just a time of day.
+		clock, err := ParseClock(fields[0])
+		if err != nil {
+			return time.Time{}, invalid
+		}
+
+		due := startOfDay(now).Add(clock)
+		if due.Before(now) {
+			due = due.AddDate(0, 0, 1)
+		}
+
+		return due, nil
+	}
+
+	clock, err := ParseClock(fields[1])
+	if dayErr != nil || err != nil {
+		return time.Time{}, invalid
+	}
+
+	return day.Add(clock), nil
+}
+
+// ParseClock reads a time of day, e.g 9am, 9:30pm or 17:00, as time since midnight.
+func ParseClock(expression string) (time.Duration, error) {
+	invalid := fmt.Errorf("invalid time '%s'", expression)
+
+	switch expression {
+	case "noon":
+		return 12 * time.Hour, nil
+	case "midnight":
+		return 0, nil
+	}
+
+	match := clockPattern.FindStringSubmatch(expression)
+	if match == nil || (match[2] == "" && match[3] == "") {
+		return 0, invalid
+	}
+
+	hour, _ := strconv.Atoi(match[1])
+	minute := 0
+	if match[2] != "" {
+		minute, _ = strconv.Atoi(match[2])
+	}
+
+	switch match[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, invalid
+		}
+
+		hour = hour % 12
+		if match[3] == "pm" {
+			hour += 12
+		}
+	default:
+		if hour > 23 {
+			return 0, invalid
+		}
+	}
+
+	if minute > 59 {
+		return 0, invalid
+	}
+
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, nil
+}
+
+// Parse a day, returning the start of it.
+func parseDueDay(expression string, now time.Time) (time.Time, error) {
+	today := startOfDay(now)
+
+	switch expression {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	if weekday, exists := weekdays[expression]; exists {
+		days := (int(weekday) - int(today.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+
+		return today.AddDate(0, 0, days), nil
+	}
+
+	return time.ParseInLocation("2006-01-02", expression, now.Location())
+}
+
+// ParseOffset reads a length of time such as 30m, 2h, 3d or 1w.
+func ParseOffset(expression string) (time.Duration, error) {
+	match := relativeDuePattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(expression)))
+	if match == nil {
+		return 0, fmt.Errorf("invalid length of time '%s', e.g 2h, 3d or 1w", expression)
+	}
+
+	amount, _ := strconv.Atoi(match[1])
+	unit := map[string]time.Duration{
+		"m": time.Minute,
+		"h": time.Hour,
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}[match[2]]
+
+	return time.Duration(amount) * unit, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// HasClock checks if a due date expression includes a time of day.
+func HasClock(expression string) bool {
+	for _, field := range strings.Fields(expression) {
+		if IsClock(field) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsClock checks if an expression is a time of day, such as 9am.
+func IsClock(expression string) bool {
+	_, err := ParseClock(strings.ToLower(expression))
+	return err == nil
+}
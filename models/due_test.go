@@ -0,0 +1,54 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseDue(t *testing.T) {
+	// A Wednesday afternoon.
+	now := time.Date(2019, 12, 11, 14, 30, 0, 0, time.Local)
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2019, 12, day, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"today", at(11, 9, 0)},
+		{"tomorrow", at(12, 9, 0)},
+		{"Tomorrow 9am", at(12, 9, 0)},
+		{"tomorrow 9:30pm", at(12, 21, 30)},
+		{"friday", at(13, 9, 0)},
+		{"next wed noon", at(18, 12, 0)},
+		{"2019-12-20", at(20, 9, 0)},
+		{"2019-12-20 17:00", at(20, 17, 0)},
+		{"2019-12-20T17:00", at(20, 17, 0)},
+		{"5pm", at(11, 17, 0)},
+		{"12am", at(12, 0, 0)},
+		{"in 2h", at(11, 16, 30)},
+		{"3d", at(14, 14, 30)},
+		{"1w", at(18, 14, 30)},
+	}
+
+	for _, test := range tests {
+		due, err := ParseDue(test.expression, now)
+		assert.Nil(t, err, test.expression)
+		assert.EqualValues(t, test.expected, due, test.expression)
+	}
+
+	for _, invalid := range []string{"", "someday", "tomorrow 25:00", "13pm", "9", "tomorrow 9am please"} {
+		_, err := ParseDue(invalid, now)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestIsClock(t *testing.T) {
+	assert.True(t, IsClock("9am"))
+	assert.True(t, IsClock("17:00"))
+	assert.True(t, IsClock("NOON"))
+	assert.False(t, IsClock("9"))
+	assert.False(t, IsClock("buy"))
+}
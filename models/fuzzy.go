@@ -0,0 +1,163 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// FuzzyThreshold is how similar a word of a note must be to a searched word to match it,
+// from 0 to 1. Words of 4 letters or more can have one typo, e.g kubernets finds kubernetes.
+const FuzzyThreshold = 0.7
+
+// FuzzyMatch is how closely a note's content matches a fuzzy search.
+type FuzzyMatch struct {
+	// The average similarity of each searched word to its closest word in the content, from 0 to 1.
+	Score float64
+
+	// The words of the content closest to each searched word, as they're written in the content.
+	Spans []string
+}
+
+// A word in text, by byte offset.
+type fuzzyWord struct {
+	start int
+	end   int
+	text  string
+}
+
+// MatchFuzzy compares content with a search, word by word. Words which contain a searched word
+// match it exactly, otherwise they're compared by edit distance. Returns whether every searched
+// word is at least FuzzyThreshold similar to a word in the content.
+func MatchFuzzy(content string, search string) (FuzzyMatch, bool) {
+	searched := splitWords(search)
+	words := splitWords(content)
+
+	var match FuzzyMatch
+	if len(searched) == 0 {
+		return match, false
+	}
+
+	for _, needle := range searched {
+		best, bestSimilarity := -1, 0.0
+
+		for i, word := range words {
+			if similarity := wordSimilarity(word.text, needle.text); similarity > bestSimilarity {
+				best, bestSimilarity = i, similarity
+			}
+		}
+
+		if bestSimilarity < FuzzyThreshold {
+			return FuzzyMatch{}, false
+		}
+
+		match.Score += bestSimilarity / float64(len(searched))
+		match.Spans = append(match.Spans, content[words[best].start:words[best].end])
+	}
+
+	return match, true
+}
+
+// Trigrams lists the distinct three letter sequences of each word in text, lowercase.
+// Words are padded with a space either side, so short words and word boundaries have trigrams too.
+func Trigrams(text string) []string {
+	seen := make(map[string]bool)
+	trigrams := make([]string, 0)
+
+	for _, word := range splitWords(text) {
+		padded := []rune(" " + word.text + " ")
+
+		for i := 0; i+3 <= len(padded); i++ {
+			trigram := string(padded[i : i+3])
+
+			if !seen[trigram] {
+				seen[trigram] = true
+				trigrams = append(trigrams, trigram)
+			}
+		}
+	}
+
+	return trigrams
+}
+
+// FuzzyWords splits a search into the lowercase words MatchFuzzy compares.
+func FuzzyWords(search string) []string {
+	words := splitWords(search)
+	texts := make([]string, 0, len(words))
+
+	for _, word := range words {
+		texts = append(texts, word.text)
+	}
+
+	return texts
+}
+
+// Split text into lowercase words of letters and digits.
+func splitWords(text string) []fuzzyWord {
+	var words []fuzzyWord
+	start := -1
+
+	for i, r := range text + " " {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+
+		if isWordRune && start == -1 {
+			start = i
+		} else if !isWordRune && start != -1 {
+			words = append(words, fuzzyWord{start: start, end: i, text: strings.ToLower(text[start:i])})
+			start = -1
+		}
+	}
+
+	return words
+}
+
+// How similar a word is to a searched word, 1 when it contains it.
+func wordSimilarity(word string, searched string) float64 {
+	if strings.Contains(word, searched) {
+		return 1
+	}
+
+	a, b := []rune(word), []rune(searched)
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+
+	return 1 - float64(editDistance(a, b))/float64(longest)
+}
+
+// The Levenshtein distance between two words.
+func editDistance(a []rune, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func minInt(first int, rest ...int) int {
+	for _, value := range rest {
+		if value < first {
+			first = value
+		}
+	}
+
+	return first
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMatchFuzzy(t *testing.T) {
+	tests := []struct {
+		content string
+		search  string
+		matches bool
+		spans   []string
+	}{
+		{"Restarted the Kubernetes deployment", "kubernets", true, []string{"Kubernetes"}},
+		{"git rebase -i HEAD~3", "rebse", true, []string{"rebase"}},
+		{"Restarted the Kubernetes deployment", "KUBE deploymnt", true, []string{"Kubernetes", "deployment"}},
+		{"Restarted the Kubernetes deployment", "kubernets rebse", false, nil},
+		// Short words must match exactly.
+		{"the cat sat", "bat", false, nil},
+		{"", "anything", false, nil},
+		{"anything", "  ", false, nil},
+	}
+
+	for _, test := range tests {
+		match, matches := MatchFuzzy(test.content, test.search)
+		assert.EqualValues(t, test.matches, matches, test.search)
+		assert.EqualValues(t, test.spans, match.Spans, test.search)
+	}
+
+	// Closer matches score higher.
+	exact, _ := MatchFuzzy("kubernetes", "kubernetes")
+	typo, _ := MatchFuzzy("kubernetes", "kubernets")
+	assert.EqualValues(t, 1, exact.Score)
+	assert.True(t, typo.Score < exact.Score)
+	assert.True(t, typo.Score >= FuzzyThreshold)
+}
+
+func TestTrigrams(t *testing.T) {
+	assert.EqualValues(t, []string{" gi", "git", "it ", " ok", "ok "}, Trigrams("Git, ok git"))
+	assert.EqualValues(t, []string{" a ", " é "}, Trigrams("a é"))
+	assert.Empty(t, Trigrams(" -- "))
+}
+
+func TestFuzzyWords(t *testing.T) {
+	assert.EqualValues(t, []string{"head", "3", "git"}, FuzzyWords("HEAD~3  git"))
+	assert.Empty(t, FuzzyWords(" -- "))
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"kitten", "sitting", 3},
+		{"rebase", "rebse", 1},
+		{"", "abc", 3},
+	}
+
+	for _, test := range tests {
+		assert.EqualValues(t, test.expected, editDistance([]rune(test.a), []rune(test.b)), test.a+" "+test.b)
+	}
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A #tag found in note content, by byte offset.
+type hashtag struct {
+	start int
+	end   int
+	tag   string
+}
+
+// ExtractHashtags finds the #tags written in note content, normalized and in the order they appear.
+//
+// A hashtag starts with a letter, and must follow whitespace or an opening bracket, so URL
+// fragments (example.com/page#section) and headings (# Title) aren't tags. Hashtags in
+// fenced code blocks and inline code are ignored.
+func ExtractHashtags(content string) []string {
+	seen := make(map[string]bool)
+	tags := make([]string, 0)
+
+	for _, found := range findHashtags(content) {
+		tag := NormalizeTag(found.tag)
+
+		if len(tag) > 0 && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// StripHashtags removes the #tags found by ExtractHashtags from note content.
+func StripHashtags(content string) string {
+	found := findHashtags(content)
+
+	// Strip from the end, so earlier offsets stay valid.
+	for i := len(found) - 1; i >= 0; i-- {
+		start, end := found[i].start, found[i].end
+
+		if start > 0 && content[start-1] == ' ' && (end == len(content) || !isHashtagRune(rune(content[end]))) {
+			start--
+		} else if end < len(content) && content[end] == ' ' {
+			end++
+		}
+
+		content = content[:start] + content[end:]
+	}
+
+	return strings.TrimSpace(content)
+}
+
+func findHashtags(content string) []hashtag {
+	var found []hashtag
+	inFence := false
+	offset := 0
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		} else if !inFence {
+			found = append(found, findLineHashtags(line, offset)...)
+		}
+
+		offset += len(line)
+	}
+
+	return found
+}
+
+func findLineHashtags(line string, offset int) []hashtag {
+	var found []hashtag
+	inCode := false
+	previous := ' '
+
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+
+		if r == '`' {
+			inCode = !inCode
+		} else if r == '#' && !inCode && (unicode.IsSpace(previous) || strings.ContainsRune("([{", previous)) {
+			if end := hashtagEnd(line, i+size); end > i+size {
+				found = append(found, hashtag{start: offset + i, end: offset + end, tag: line[i+size : end]})
+
+				previous = '#'
+				i = end
+				continue
+			}
+		}
+
+		previous = r
+		i += size
+	}
+
+	return found
+}
+
+// Find where a hashtag's name, beginning at start, ends. Returns start when there's no name.
+func hashtagEnd(line string, start int) int {
+	if first, _ := utf8.DecodeRuneInString(line[start:]); !unicode.IsLetter(first) {
+		return start
+	}
+
+	end := start
+	for end < len(line) {
+		r, size := utf8.DecodeRuneInString(line[end:])
+		if !isHashtagRune(r) {
+			break
+		}
+
+		end += size
+	}
+
+	// A trailing separator ends a sentence or path, rather than starting a child tag.
+	for end > start && strings.ContainsRune(TagSeparator+"-_", rune(line[end-1])) {
+		end--
+	}
+
+	return end
+}
+
+func isHashtagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || string(r) == TagSeparator
+}
+
+// MergeTags adds tags which aren't already present, keeping the existing order.
+func MergeTags(tags []string, additional []string) []string {
+	merged := append([]string{}, tags...)
+
+	for _, tag := range additional {
+		exists := false
+		for _, existing := range merged {
+			if existing == tag {
+				exists = true
+				break
+			}
+		}
+
+		if !exists {
+			merged = append(merged, tag)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected []string
+	}{
+		{"#git rebase -i COMMIT #cli", []string{"git", "cli"}},
+		{"Fixed the #Work/Infra issue, see #k8s.", []string{"work/infra", "k8s"}},
+		{"duplicate #git and #GIT", []string{"git"}},
+		{"see https://example.com/page#section", []string{}},
+		{"# Heading\n## Subheading", []string{}},
+		{"issue #12 and (#urgent)", []string{"urgent"}},
+		{"run `git log #not-a-tag` #git", []string{"git"}},
+		{"```\n# comment #not-a-tag\n```\n#after", []string{"after"}},
+		{"a#b #tag-", []string{"tag"}},
+		{"", []string{}},
+	}
+
+	for _, test := range tests {
+		assert.EqualValues(t, test.expected, ExtractHashtags(test.content), test.content)
+	}
+}
+
+func TestStripHashtags(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"#git rebase -i COMMIT #cli", "rebase -i COMMIT"},
+		{"use #git now.", "use now."},
+		{"see #k8s.", "see."},
+		{"see https://example.com/page#section", "see https://example.com/page#section"},
+		{"```\n#keep\n```", "```\n#keep\n```"},
+		{"first #line\nsecond line", "first\nsecond line"},
+	}
+
+	for _, test := range tests {
+		assert.EqualValues(t, test.expected, StripHashtags(test.content), test.content)
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	assert.EqualValues(t, []string{"git", "cli", "go"}, MergeTags([]string{"git", "cli"}, []string{"cli", "go"}))
+	assert.EqualValues(t, []string{"go"}, MergeTags(nil, []string{"go"}))
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MinLinkLength is the shortest ID prefix a link can use.
+const MinLinkLength = 6
+
+// Links look like [[ID]] or [[ID-PREFIX]].
+var linkPattern = regexp.MustCompile(`\[\[([0-9a-fA-F-]+)\]\]`)
+
+// Link is a reference from one note's content to another note.
+type Link struct {
+	// The ID, or ID prefix, that was linked to.
+	Target string
+
+	// The linked note, nil when the link is broken.
+	Note *Note
+
+	// Whether more than one note has the linked prefix.
+	Ambiguous bool
+}
+
+// Broken checks if a link doesn't lead to exactly one note.
+func (link Link) Broken() bool {
+	return link.Note == nil
+}
+
+// ExtractLinks finds the note IDs, or ID prefixes, linked to by note content, in the order they appear.
+func ExtractLinks(content string) []string {
+	seen := make(map[string]bool)
+	targets := make([]string, 0)
+
+	for _, match := range linkPattern.FindAllStringSubmatch(content, -1) {
+		target := strings.ToLower(match[1])
+
+		if len(target) >= MinLinkLength && !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
+
+// ReplaceLinks replaces each link in content with what fn returns for its target, lowercase
+// like ExtractLinks. Links too short to follow, or which fn doesn't replace, are left as they are.
+func ReplaceLinks(content string, fn func(target string) (string, bool)) string {
+	return linkPattern.ReplaceAllStringFunc(content, func(link string) string {
+		target := strings.ToLower(linkPattern.FindStringSubmatch(link)[1])
+		if len(target) < MinLinkLength {
+			return link
+		}
+
+		if replacement, ok := fn(target); ok {
+			return replacement
+		}
+
+		return link
+	})
+}
+
+// LinksTo checks if a link target leads to a note ID.
+func LinksTo(target string, noteId string) bool {
+	return strings.HasPrefix(strings.ToLower(noteId), target)
+}
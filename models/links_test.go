@@ -0,0 +1,37 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExtractLinks(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected []string
+	}{
+		{"fixed by [[3F2A9C1B]], see [[3f2a9c1b]]", []string{"3f2a9c1b"}},
+		{"[[6ba7b810-9dad-11d1-80b4-00c04fd430c8]] then [[abcdef]]", []string{"6ba7b810-9dad-11d1-80b4-00c04fd430c8", "abcdef"}},
+		{"too short [[abc]]", []string{}},
+		{"not an id [[meeting notes]]", []string{}},
+		{"[single] brackets [abcdef]", []string{}},
+	}
+
+	for _, test := range tests {
+		assert.EqualValues(t, test.expected, ExtractLinks(test.content), test.content)
+	}
+}
+
+func TestReplaceLinks(t *testing.T) {
+	replaced := ReplaceLinks("see [[3F2A9C1B]], [[abc]] and [[ffffff]]", func(target string) (string, bool) {
+		return "<" + target + ">", target != "ffffff"
+	})
+
+	assert.EqualValues(t, "see <3f2a9c1b>, [[abc]] and [[ffffff]]", replaced)
+}
+
+func TestLinksTo(t *testing.T) {
+	assert.True(t, LinksTo("6ba7b810", "6ba7b810-9dad-11d1-80b4-00c04fd430c8"))
+	assert.True(t, LinksTo("6ba7b810", "6BA7B810-9dad-11d1-80b4-00c04fd430c8"))
+	assert.False(t, LinksTo("6ba7b811", "6ba7b810-9dad-11d1-80b4-00c04fd430c8"))
+}
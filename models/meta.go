@@ -0,0 +1,181 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operators a metadata filter can compare values with.
+const (
+	MetaExists       = ""
+	MetaEquals       = "="
+	MetaNotEquals    = "!="
+	MetaGreater      = ">"
+	MetaGreaterEqual = ">="
+	MetaLess         = "<"
+	MetaLessEqual    = "<="
+)
+
+// Checked in order, so longer operators are matched before their prefixes.
+var metaOperators = []string{MetaNotEquals, MetaGreaterEqual, MetaLessEqual, MetaEquals, MetaGreater, MetaLess}
+
+var errEmptyMetaKey = errors.New("empty metadata key")
+
+// MetaFilter matches notes by a metadata key.
+// Without an operator, a note only needs to have the key.
+// Equality ignores case, and the ordering operators compare numerically.
+type MetaFilter struct {
+	Key      string
+	Operator string
+	Value    string
+}
+
+// IsNumeric checks if the filter compares values as numbers.
+func (filter MetaFilter) IsNumeric() bool {
+	switch filter.Operator {
+	case MetaGreater, MetaGreaterEqual, MetaLess, MetaLessEqual:
+		return true
+	}
+
+	return false
+}
+
+// Matches checks if a note's metadata satisfies the filter.
+func (filter MetaFilter) Matches(meta map[string]string) bool {
+	value, exists := meta[filter.Key]
+	if !exists {
+		return false
+	}
+
+	switch filter.Operator {
+	case MetaExists:
+		return true
+	case MetaEquals:
+		return strings.EqualFold(value, filter.Value)
+	case MetaNotEquals:
+		return !strings.EqualFold(value, filter.Value)
+	}
+
+	number, isNumber := ParseMetaNumber(value)
+	if !isNumber {
+		return false
+	}
+
+	operand, _ := ParseMetaNumber(filter.Value)
+
+	switch filter.Operator {
+	case MetaGreater:
+		return number > operand
+	case MetaGreaterEqual:
+		return number >= operand
+	case MetaLess:
+		return number < operand
+	case MetaLessEqual:
+		return number <= operand
+	}
+
+	return false
+}
+
+// ParseMetaNumber reads a metadata value as a number, if it is one.
+func ParseMetaNumber(value string) (float64, bool) {
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return number, true
+}
+
+// NormalizeMetaKey cleans a metadata key. Keys are case insensitive.
+func NormalizeMetaKey(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
+
+// ParseMeta reads key=value pairs into a metadata map.
+func ParseMeta(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	meta := make(map[string]string, len(pairs))
+
+	for _, pair := range pairs {
+		separator := strings.Index(pair, "=")
+		if separator == -1 {
+			return nil, fmt.Errorf("invalid metadata '%s', expected key=value", pair)
+		}
+
+		key := NormalizeMetaKey(pair[:separator])
+		if len(key) == 0 {
+			return nil, errEmptyMetaKey
+		}
+
+		meta[key] = strings.TrimSpace(pair[separator+1:])
+	}
+
+	return meta, nil
+}
+
+// ParseMetaFilter reads a filter such as project=apollo, priority>=2 or ticket.
+func ParseMetaFilter(expression string) (MetaFilter, error) {
+	// The key ends at the earliest operator, so title=a>=b compares title with a>=b.
+	// Of the operators starting there the longest wins, so >= isn't read as >.
+	index, operator := -1, ""
+	for _, candidate := range metaOperators {
+		at := strings.Index(expression, candidate)
+		if at == -1 {
+			continue
+		}
+
+		if index == -1 || at < index || (at == index && len(candidate) > len(operator)) {
+			index, operator = at, candidate
+		}
+	}
+
+	if index != -1 {
+		filter := MetaFilter{
+			Key:      NormalizeMetaKey(expression[:index]),
+			Operator: operator,
+			Value:    strings.TrimSpace(expression[index+len(operator):]),
+		}
+
+		if len(filter.Key) == 0 {
+			return MetaFilter{}, errEmptyMetaKey
+		}
+
+		if filter.IsNumeric() {
+			if _, isNumber := ParseMetaNumber(filter.Value); !isNumber {
+				return MetaFilter{}, fmt.Errorf("invalid metadata filter '%s', %s needs a number", expression, operator)
+			}
+		}
+
+		return filter, nil
+	}
+
+	key := NormalizeMetaKey(expression)
+	if len(key) == 0 {
+		return MetaFilter{}, errEmptyMetaKey
+	}
+
+	return MetaFilter{Key: key, Operator: MetaExists}, nil
+}
+
+// FormatMeta renders metadata as key=value pairs, sorted by key.
+func FormatMeta(meta map[string]string) []string {
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+meta[key])
+	}
+
+	return pairs
+}
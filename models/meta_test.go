@@ -0,0 +1,77 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseMetaFilter(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   MetaFilter
+		valid      bool
+	}{
+		{"project=apollo", MetaFilter{"project", MetaEquals, "apollo"}, true},
+		{" Ticket = ABC-12 ", MetaFilter{"ticket", MetaEquals, "ABC-12"}, true},
+		{"priority>=2", MetaFilter{"priority", MetaGreaterEqual, "2"}, true},
+		{"priority<2.5", MetaFilter{"priority", MetaLess, "2.5"}, true},
+		{"status!=done", MetaFilter{"status", MetaNotEquals, "done"}, true},
+		{"ticket", MetaFilter{"ticket", MetaExists, ""}, true},
+		{"title=a>=b", MetaFilter{"title", MetaEquals, "a>=b"}, true},
+		{"formula!=x<=y", MetaFilter{"formula", MetaNotEquals, "x<=y"}, true},
+		{"priority<=2=", MetaFilter{}, false},
+		{"priority>high", MetaFilter{}, false},
+		{"=apollo", MetaFilter{}, false},
+		{"", MetaFilter{}, false},
+	}
+
+	for _, test := range tests {
+		filter, err := ParseMetaFilter(test.expression)
+
+		if test.valid {
+			assert.Nil(t, err, test.expression)
+			assert.EqualValues(t, test.expected, filter, test.expression)
+		} else {
+			assert.NotNil(t, err, test.expression)
+		}
+	}
+}
+
+func TestMetaFilter_Matches(t *testing.T) {
+	meta := map[string]string{"project": "Apollo", "priority": "3", "size": "large"}
+
+	tests := []struct {
+		expression string
+		matches    bool
+	}{
+		{"project", true},
+		{"ticket", false},
+		{"project=apollo", true},
+		{"project!=apollo", false},
+		{"project!=gemini", true},
+		{"ticket!=ABC-12", false},
+		{"priority>2", true},
+		{"priority>3", false},
+		{"priority>=3", true},
+		{"priority<=2", false},
+		{"size>1", false},
+	}
+
+	for _, test := range tests {
+		filter, err := ParseMetaFilter(test.expression)
+		assert.Nil(t, err, test.expression)
+		assert.EqualValues(t, test.matches, filter.Matches(meta), test.expression)
+	}
+}
+
+func TestParseMeta(t *testing.T) {
+	meta, err := ParseMeta([]string{"Project=apollo", "ticket = ABC-12", "empty="})
+	assert.Nil(t, err)
+	assert.EqualValues(t, map[string]string{"project": "apollo", "ticket": "ABC-12", "empty": ""}, meta)
+
+	_, err = ParseMeta([]string{"no-separator"})
+	assert.NotNil(t, err)
+
+	_, err = ParseMeta([]string{"=value"})
+	assert.NotNil(t, err)
+}
@@ -2,15 +2,38 @@ package models
 
 import (
 	uuid "github.com/satori/go.uuid"
+	"strings"
 	"time"
 )
 
 // Main note model.
 type Note struct {
-	ID        string
-	Tags      []string
-	Content   string
+	ID      string
+	Tags    []string
+	Content string
+
+	// Optional title, see GetTitle.
+	Title string
+
+	// Arbitrary key/value pairs, e.g project=apollo
+	Meta map[string]string
+
 	Timestamp time.Time
+
+	// When the note was last changed, zero if it never has been.
+	UpdatedAt time.Time
+
+	// When the note is due, zero if it isn't.
+	Due time.Time
+
+	// Whether the note is a task, and how it's progressing.
+	Status Status
+
+	// When the note was marked done or cancelled, zero if it hasn't been.
+	CompletedAt time.Time
+
+	// Pinned notes are listed before others.
+	Pinned bool
 }
 
 // NewNote creates a note with a given content and tags.
@@ -26,9 +49,39 @@ func NewNote(tags []string, content string) Note {
 // Clone creates a copy of a note.
 func (note Note) Clone() Note {
 	return Note{
-		ID:        note.ID,
-		Tags:      note.Tags,
-		Content:   note.Content,
-		Timestamp: note.Timestamp,
+		ID:          note.ID,
+		Tags:        note.Tags,
+		Content:     note.Content,
+		Title:       note.Title,
+		Meta:        note.Meta,
+		Timestamp:   note.Timestamp,
+		UpdatedAt:   note.UpdatedAt,
+		Due:         note.Due,
+		Status:      note.Status,
+		CompletedAt: note.CompletedAt,
+		Pinned:      note.Pinned,
+	}
+}
+
+// IsOverdue checks if a note was due before a given time and still isn't complete.
+func (note Note) IsOverdue(now time.Time) bool {
+	return !note.Due.IsZero() && note.CompletedAt.IsZero() && note.Due.Before(now)
+}
+
+// GetTitle returns the note's title, or the first line of its content when it wasn't given one.
+func (note Note) GetTitle() string {
+	if len(note.Title) > 0 {
+		return note.Title
 	}
+
+	return FirstLine(note.Content)
+}
+
+// FirstLine returns the first line of a string, without surrounding whitespace.
+func FirstLine(content string) string {
+	if newline := strings.Index(content, "\n"); newline != -1 {
+		content = content[:newline]
+	}
+
+	return strings.TrimSpace(content)
 }
@@ -0,0 +1,21 @@
+package models
+
+import "testing"
+
+func TestNote_GetTitle(t *testing.T) {
+	tests := []struct {
+		note     Note
+		expected string
+	}{
+		{Note{Title: "Standup", Content: "first line\nsecond line"}, "Standup"},
+		{Note{Content: "  first line  \nsecond line"}, "first line"},
+		{Note{Content: "only line"}, "only line"},
+		{Note{Content: ""}, ""},
+	}
+
+	for _, test := range tests {
+		if actual := test.note.GetTitle(); actual != test.expected {
+			t.Fatalf("expected title '%s', got '%s'", test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// How many of the most used tags a report highlights.
+const ReportTopTags = 5
+
+// Report summarises the notes written over a period.
+type Report struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+
+	NoteCount int `json:"note_count"`
+
+	// Words are separated by whitespace.
+	WordCount int `json:"word_count"`
+
+	// Every day of the period in order, including days without notes.
+	Days []DayCount `json:"days"`
+
+	// Notes written in each hour of the day, from midnight.
+	Hours [24]int `json:"hours"`
+
+	// Tags of the notes written, most used first.
+	Tags []TagCount `json:"tags"`
+
+	// The first ReportTopTags of Tags.
+	TopTags []TagCount `json:"top_tags"`
+
+	LongestStreak Streak `json:"longest_streak"`
+}
+
+// DayCount is how many notes were written on a day, e.g 2019-12-08, see DailyDateFormat.
+type DayCount struct {
+	Day   string `json:"day"`
+	Count int    `json:"count"`
+}
+
+// Streak is a run of consecutive days with notes.
+type Streak struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+	Days  int    `json:"days"`
+}
+
+// LongestStreak finds the longest run of consecutive days with notes, the earliest when tied.
+// Days are expected in order.
+func LongestStreak(days []DayCount) Streak {
+	var longest, current Streak
+	var previous time.Time
+
+	for _, day := range days {
+		if day.Count == 0 {
+			continue
+		}
+
+		date, err := time.Parse(DailyDateFormat, day.Day)
+		if err != nil {
+			continue
+		}
+
+		if current.Days > 0 && date.Equal(previous.AddDate(0, 0, 1)) {
+			current.End = day.Day
+			current.Days++
+		} else {
+			current = Streak{Start: day.Day, End: day.Day, Days: 1}
+		}
+
+		if current.Days > longest.Days {
+			longest = current
+		}
+
+		previous = date
+	}
+
+	return longest
+}
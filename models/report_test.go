@@ -0,0 +1,35 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLongestStreak(t *testing.T) {
+	tests := []struct {
+		days     []DayCount
+		expected Streak
+	}{
+		{nil, Streak{}},
+		{[]DayCount{{"2019-12-08", 0}}, Streak{}},
+		{[]DayCount{{"2019-12-08", 2}}, Streak{Start: "2019-12-08", End: "2019-12-08", Days: 1}},
+		{
+			[]DayCount{{"2019-12-01", 1}, {"2019-12-02", 3}, {"2019-12-03", 0}, {"2019-12-04", 1}, {"2019-12-05", 1}, {"2019-12-06", 1}},
+			Streak{Start: "2019-12-04", End: "2019-12-06", Days: 3},
+		},
+		// Across the end of a month, and ties keep the earliest.
+		{
+			[]DayCount{{"2019-11-30", 1}, {"2019-12-01", 1}, {"2019-12-05", 1}, {"2019-12-06", 1}},
+			Streak{Start: "2019-11-30", End: "2019-12-01", Days: 2},
+		},
+		// Days without notes may be missing entirely.
+		{
+			[]DayCount{{"2019-12-01", 1}, {"2019-12-03", 1}},
+			Streak{Start: "2019-12-01", End: "2019-12-01", Days: 1},
+		},
+	}
+
+	for _, test := range tests {
+		assert.EqualValues(t, test.expected, LongestStreak(test.days))
+	}
+}
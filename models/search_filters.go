@@ -1,9 +1,38 @@
 package models
 
+import "time"
+
 type SearchFilters struct {
 	DateRange *DateRange
 
 	Tags []string
 
+	// Match every tag beneath the searched tags as well, see TagWithin.
+	TagTree bool
+
 	Content string
+
+	// Content loosely matching every word, allowing for typos, see MatchFuzzy.
+	Fuzzy string
+
+	// Content matching a Go regular expression, e.g TODO\(\w+\).
+	Regex string
+
+	// Matched against each note's title, see Note.GetTitle.
+	Title string
+
+	// Every filter must match.
+	Meta []MetaFilter
+
+	// Only notes due at or before this time, when set.
+	DueBefore time.Time
+
+	// Only notes which haven't been marked complete.
+	Incomplete bool
+
+	// Only tasks with any of these statuses, when set.
+	Status []Status
+
+	// Only pinned notes.
+	Pinned bool
 }
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Stats describes the contents of a note database.
+type Stats struct {
+	NoteCount int
+	TagCount  int
+
+	// Size of the database on disk, in bytes, including its write-ahead log and shared memory files.
+	FileSize int64
+
+	OldestNote *time.Time
+	NewestNote *time.Time
+
+	// The largest notes by content length, largest first.
+	LargestNotes []*Note
+}
+
+// OrphanTag is a tag whose note no longer exists.
+type OrphanTag struct {
+	NoteID string
+	Tag    string
+}
+
+// IntegrityReport is the result of checking a database for corruption
+// and dangling rows.
+type IntegrityReport struct {
+	// Problems reported by the storage engine itself.
+	Problems []string
+
+	OrphanTags []OrphanTag
+
+	// Whether the orphaned rows were removed.
+	Fixed bool
+}
+
+// Ok checks if the report found nothing wrong.
+func (report IntegrityReport) Ok() bool {
+	return len(report.Problems) == 0 && (len(report.OrphanTags) == 0 || report.Fixed)
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status tracks the progress of a note which is a task.
+type Status string
+
+const (
+	// A plain note, rather than a task.
+	StatusNone Status = ""
+
+	StatusOpen      Status = "open"
+	StatusDone      Status = "done"
+	StatusCancelled Status = "cancelled"
+)
+
+// Statuses lists every status a task can have.
+var Statuses = []Status{StatusOpen, StatusDone, StatusCancelled}
+
+// ParseStatus reads a task status, e.g open, done or cancelled.
+func ParseStatus(expression string) (Status, error) {
+	status := Status(strings.ToLower(strings.TrimSpace(expression)))
+
+	for _, valid := range Statuses {
+		if status == valid {
+			return status, nil
+		}
+	}
+
+	return StatusNone, fmt.Errorf("invalid status '%s', expected open, done or cancelled", expression)
+}
+
+// IsClosed checks if a task is finished with, either done or cancelled.
+// Closed tasks record when they were closed, see Note.CompletedAt.
+func (status Status) IsClosed() bool {
+	return status == StatusDone || status == StatusCancelled
+}
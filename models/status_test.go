@@ -0,0 +1,28 @@
+package models
+
+import "testing"
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   Status
+		valid      bool
+	}{
+		{"open", StatusOpen, true},
+		{" Done ", StatusDone, true},
+		{"cancelled", StatusCancelled, true},
+		{"", StatusNone, false},
+		{"finished", StatusNone, false},
+	}
+
+	for _, test := range tests {
+		status, err := ParseStatus(test.expression)
+		if (err == nil) != test.valid {
+			t.Fatalf("expected '%s' valid to be %v, got error %v", test.expression, test.valid, err)
+		}
+
+		if status != test.expected {
+			t.Fatalf("expected status '%s' from '%s', got '%s'", test.expected, test.expression, status)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// TagSeparator divides a hierarchical tag into its levels, e.g work/infra/k8s.
+const TagSeparator = "/"
+
+// MaxTagLength is the longest a tag can be, in characters.
+const MaxTagLength = 50
+
+// NormalizeTag applies the tag policy: tags are lowercase, use dashes rather
+// than spaces, and are at most MaxTagLength characters long.
+func NormalizeTag(tag string) string {
+	tag = strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+
+	if runes := []rune(tag); len(runes) > MaxTagLength {
+		tag = string(runes[:MaxTagLength])
+	}
+
+	return tag
+}
+
+// ResolveTagAlias replaces a tag with what it's aliased to. Aliases apply to the
+// tags beneath them too, so with k8s aliased to kubernetes, k8s/prod becomes kubernetes/prod.
+// When aliases overlap, the longest one which matches wins. Tags which would be longer than
+// MaxTagLength once aliased are left as they are.
+func ResolveTagAlias(tag string, aliases map[string]string) string {
+	longest, found := "", false
+	for alias := range aliases {
+		if !TagWithin(tag, alias) {
+			continue
+		}
+
+		// Break ties by name, since map order is random.
+		if !found || len(alias) > len(longest) || (len(alias) == len(longest) && alias < longest) {
+			longest, found = alias, true
+		}
+	}
+
+	if !found {
+		return tag
+	}
+
+	renamed, _ := RenameTagTree(tag, longest, aliases[longest])
+	if utf8.RuneCountInString(renamed) > MaxTagLength {
+		return tag
+	}
+
+	return renamed
+}
+
+// TagCount is a tag and how many notes have it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TagWithin checks if tag is root, or any tag beneath it. Tags are case insensitive.
+func TagWithin(tag string, root string) bool {
+	if strings.EqualFold(tag, root) {
+		return true
+	}
+
+	prefix := root + TagSeparator
+	return len(tag) > len(prefix) && strings.EqualFold(tag[:len(prefix)], prefix)
+}
+
+// RenameTagTree renames tag if it's within from, keeping any levels beneath from.
+// Returns whether the tag was renamed.
+func RenameTagTree(tag string, from string, to string) (string, bool) {
+	if !TagWithin(tag, from) {
+		return tag, false
+	}
+
+	return to + tag[len(from):], true
+}
+
+// TagNode is a level of the tag hierarchy.
+type TagNode struct {
+	// This level's name, e.g k8s.
+	Name string
+
+	// The full tag, e.g work/infra/k8s.
+	Tag string
+
+	// How many notes have exactly this tag. Levels which are only
+	// parents of other tags have no notes.
+	Count int
+
+	Children []*TagNode
+}
+
+// BuildTagTree arranges tags into a hierarchy, sorted by name at each level.
+func BuildTagTree(tags []TagCount) []*TagNode {
+	root := &TagNode{}
+
+	for _, tagCount := range tags {
+		node := root
+
+		for _, name := range strings.Split(tagCount.Tag, TagSeparator) {
+			node = node.child(name)
+		}
+
+		node.Count += tagCount.Count
+	}
+
+	root.sort()
+	return root.Children
+}
+
+// Find or add a child level by name.
+func (node *TagNode) child(name string) *TagNode {
+	for _, child := range node.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+
+	tag := name
+	if len(node.Tag) > 0 {
+		tag = node.Tag + TagSeparator + name
+	}
+
+	child := &TagNode{Name: name, Tag: tag}
+	node.Children = append(node.Children, child)
+
+	return child
+}
+
+func (node *TagNode) sort() {
+	sort.Slice(node.Children, func(i, j int) bool {
+		return node.Children[i].Name < node.Children[j].Name
+	})
+
+	for _, child := range node.Children {
+		child.sort()
+	}
+}
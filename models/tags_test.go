@@ -0,0 +1,109 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestTagWithin(t *testing.T) {
+	tests := []struct {
+		tag      string
+		root     string
+		expected bool
+	}{
+		{"work", "work", true},
+		{"Work", "work", true},
+		{"work/infra", "work", true},
+		{"work/infra/k8s", "work/infra", true},
+		{"workshop", "work", false},
+		{"work/", "work", false},
+		{"lang/go", "work", false},
+		{"work", "work/infra", false},
+	}
+
+	for _, test := range tests {
+		assert.EqualValues(t, test.expected, TagWithin(test.tag, test.root), test.tag+" within "+test.root)
+	}
+}
+
+func TestRenameTagTree(t *testing.T) {
+	renamed, ok := RenameTagTree("work/infra/k8s", "work", "job")
+	assert.True(t, ok)
+	assert.EqualValues(t, "job/infra/k8s", renamed)
+
+	renamed, ok = RenameTagTree("work/infra", "work/infra", "ops")
+	assert.True(t, ok)
+	assert.EqualValues(t, "ops", renamed)
+
+	renamed, ok = RenameTagTree("workshop", "work", "job")
+	assert.False(t, ok)
+	assert.EqualValues(t, "workshop", renamed)
+}
+
+func TestBuildTagTree(t *testing.T) {
+	tree := BuildTagTree([]TagCount{
+		{"work/infra/k8s", 2},
+		{"lang/go", 4},
+		{"work", 1},
+		{"work/infra/terraform", 1},
+	})
+
+	assert.Len(t, tree, 2)
+
+	lang := tree[0]
+	assert.EqualValues(t, "lang", lang.Name)
+	assert.EqualValues(t, 0, lang.Count)
+	assert.Len(t, lang.Children, 1)
+	assert.EqualValues(t, "lang/go", lang.Children[0].Tag)
+	assert.EqualValues(t, 4, lang.Children[0].Count)
+
+	work := tree[1]
+	assert.EqualValues(t, "work", work.Tag)
+	assert.EqualValues(t, 1, work.Count)
+
+	infra := work.Children[0]
+	assert.EqualValues(t, "work/infra", infra.Tag)
+	assert.Len(t, infra.Children, 2)
+	assert.EqualValues(t, "k8s", infra.Children[0].Name)
+	assert.EqualValues(t, "work/infra/terraform", infra.Children[1].Tag)
+}
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected string
+	}{
+		{"git", "git"},
+		{"  GIT ", "git"},
+		{"Machine  Learning", "machine-learning"},
+		{"Work/Infra", "work/infra"},
+		{strings.Repeat("a", MaxTagLength+10), strings.Repeat("a", MaxTagLength)},
+		{"   ", ""},
+	}
+
+	for _, test := range tests {
+		assert.EqualValues(t, test.expected, NormalizeTag(test.tag), test.tag)
+	}
+}
+
+func TestResolveTagAlias(t *testing.T) {
+	aliases := map[string]string{"k8s": "kubernetes", "js": "lang/javascript"}
+
+	assert.EqualValues(t, "kubernetes", ResolveTagAlias("k8s", aliases))
+	assert.EqualValues(t, "kubernetes/prod", ResolveTagAlias("k8s/prod", aliases))
+	assert.EqualValues(t, "lang/javascript", ResolveTagAlias("js", aliases))
+	assert.EqualValues(t, "jsx", ResolveTagAlias("jsx", aliases))
+	assert.EqualValues(t, "git", ResolveTagAlias("git", nil))
+
+	overlapping := map[string]string{"work": "job", "work/infra": "ops", "work/infra/k8s": "ops/kubernetes"}
+	for i := 0; i < 20; i++ {
+		assert.EqualValues(t, "ops/kubernetes/prod", ResolveTagAlias("work/infra/k8s/prod", overlapping))
+		assert.EqualValues(t, "ops/ci", ResolveTagAlias("work/infra/ci", overlapping))
+		assert.EqualValues(t, "job/meetings", ResolveTagAlias("work/meetings", overlapping))
+	}
+
+	long := map[string]string{"k": strings.Repeat("a", MaxTagLength)}
+	assert.EqualValues(t, long["k"], ResolveTagAlias("k", long))
+	assert.EqualValues(t, "k/prod", ResolveTagAlias("k/prod", long))
+}
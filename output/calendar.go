@@ -0,0 +1,76 @@
+package output
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"strings"
+	"time"
+)
+
+// Width of a day in the calendar, including the marker for days with notes.
+const calendarCellWidth = 4
+
+// Print a month as a calendar, starting on Sunday. Days with notes are marked, and
+// listed after the calendar with how many notes they have.
+func (printer printer) PrintCalendar(month time.Time, counts map[int]int, options Options) {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	days := first.AddDate(0, 1, -1).Day()
+
+	title := first.Format("January 2006")
+	width := 7 * calendarCellWidth
+	fmt.Println(strings.Repeat(" ", (width-len(title))/2) + title)
+
+	var line strings.Builder
+	for _, weekday := range []string{"Su", "Mo", "Tu", "We", "Th", "Fr", "Sa"} {
+		line.WriteString(fmt.Sprintf("%*s ", calendarCellWidth-1, weekday))
+	}
+	fmt.Println(strings.TrimRight(line.String(), " "))
+
+	line.Reset()
+	line.WriteString(strings.Repeat(" ", int(first.Weekday())*calendarCellWidth))
+
+	for day := 1; day <= days; day++ {
+		cell := fmt.Sprintf("%*d", calendarCellWidth-1, day)
+		marker := " "
+
+		if counts[day] > 0 {
+			marker = "*"
+			if options.Pretty {
+				cell = color.New(color.FgGreen, color.Bold).Sprint(cell)
+			}
+		}
+
+		line.WriteString(cell + marker)
+
+		if weekday := first.AddDate(0, 0, day-1).Weekday(); weekday == time.Saturday || day == days {
+			fmt.Println(strings.TrimRight(line.String(), " "))
+			line.Reset()
+		}
+	}
+
+	fmt.Println()
+
+	total := 0
+	for day := 1; day <= days; day++ {
+		if counts[day] == 0 {
+			continue
+		}
+
+		total += counts[day]
+		fmt.Println(fmt.Sprintf("%s  %s", first.AddDate(0, 0, day-1).Format("Jan 02 Mon"), pluralizeNotes(counts[day])))
+	}
+
+	if total > 0 {
+		fmt.Println()
+	}
+
+	printer.PrintNoteCount(total)
+}
+
+func pluralizeNotes(count int) string {
+	if count == 1 {
+		return "1 note"
+	}
+
+	return fmt.Sprintf("%d notes", count)
+}
@@ -2,6 +2,8 @@ package output
 
 import (
 	"github.com/fatih/color"
+	"github.com/ricanontherun/short-form/utils"
+	"sort"
 	"strings"
 )
 
@@ -28,6 +30,53 @@ func highlightNeedle(original string, highlight string, printer *color.Color) st
 	return original
 }
 
+// The text to highlight in content, besides SearchContent: the given highlights and any regex matches.
+func findHighlights(content string, options Options) []string {
+	highlights := append([]string{}, options.Highlights...)
+
+	if len(options.SearchRegex) > 0 {
+		if compiled, err := utils.CompileRegexp(options.SearchRegex); err == nil {
+			for _, match := range compiled.FindAllString(content, -1) {
+				// Patterns which can match nothing, e.g a*, find empty matches.
+				if len(match) > 0 {
+					highlights = append(highlights, match)
+				}
+			}
+		}
+	}
+
+	return utils.SliceUniqueStrings(highlights)
+}
+
+// highlightNeedles highlights the occurrences of several needles in original, longest first.
+func highlightNeedles(original string, needles []string, printer *color.Color) string {
+	sorted := append([]string{}, needles...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+
+	return highlightEachNeedle(original, sorted, printer)
+}
+
+// Each needle is only looked for in the text between occurrences of the needles before it,
+// so the terminal codes of one highlight are never highlighted by another.
+func highlightEachNeedle(original string, needles []string, printer *color.Color) string {
+	if len(needles) == 0 {
+		return original
+	}
+
+	if len(needles) == 1 {
+		return highlightNeedle(original, needles[0], printer)
+	}
+
+	parts := strings.Split(original, needles[0])
+	for i, part := range parts {
+		parts[i] = highlightEachNeedle(part, needles[1:], printer)
+	}
+
+	return strings.Join(parts, printer.Sprint(needles[0]))
+}
+
 // TODO: This could be much more efficient.
 func parseHighlights(highlightString string, original string) []highlight {
 	var highlights []highlight
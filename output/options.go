@@ -3,7 +3,16 @@ package output
 // Options for how output should be printed to the terminal.
 type Options struct {
 	SearchContent string
-	Detailed      bool
-	Pretty        bool
-	SearchTags    []string
+
+	// Regular expression whose matches are highlighted in note content.
+	SearchRegex string
+
+	// Words to highlight in note content, e.g the closest matches of a fuzzy search.
+	Highlights []string
+	Detailed   bool
+	Pretty     bool
+	SearchTags []string
+
+	// Print each note on a single line, by title.
+	OneLine bool
 }
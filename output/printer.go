@@ -5,45 +5,156 @@ import (
 	"github.com/fatih/color"
 	"github.com/ricanontherun/short-form/models"
 	"strings"
+	"time"
 )
 
 type Printer interface {
 	PrintNotes([]*models.Note, Options)
 	PrintNote(*models.Note, Options)
+
+	// Print how many notes were found, for use after notes are streamed.
+	PrintNoteCount(int)
+
+	// Print a month's calendar, given how many notes were written on each day of it.
+	PrintCalendar(month time.Time, counts map[int]int, options Options)
+
+	// Print a report in a format, see ReportFormatText.
+	PrintReport(report *models.Report, format string) error
 }
 
-type printer struct{}
+type printer struct {
+	// The current time, which notes are overdue relative to.
+	now func() time.Time
+}
 
-func NewPrinter() Printer {
-	return printer{}
+func NewPrinter(now func() time.Time) Printer {
+	return printer{now: now}
 }
 
 func (printer printer) PrintNotes(notes []*models.Note, options Options) {
-	noteCount := len(notes)
+	printer.PrintNoteCount(len(notes))
+
+	if len(notes) > 0 {
+		fmt.Println()
+	}
+
+	for _, note := range notes {
+		printer.PrintNote(note, options)
+	}
+}
+
+func (printer printer) PrintNoteCount(noteCount int) {
 	if noteCount == 1 {
 		fmt.Println("1 note found")
 	} else {
 		fmt.Println(fmt.Sprintf("%d notes found", noteCount))
 	}
+}
 
-	if noteCount > 0 {
-		fmt.Println()
+// Maximum length of a title in one line output.
+const oneLineTitleLength = 60
+
+const timestampFormat = "Jan 02 2006 03:04 PM"
+
+func (printer printer) PrintNote(note *models.Note, options Options) {
+	if options.OneLine {
+		printer.printNoteLine(note, options)
+		return
 	}
 
-	for _, note := range notes {
-		printer.PrintNote(note, options)
+	bits := printer.formatNoteHeader(note, options)
+
+	if due := printer.formatDue(note, options); len(due) > 0 {
+		bits = append(bits, due)
+	}
+
+	if options.Detailed && !note.UpdatedAt.IsZero() {
+		bits = append(bits, "updated "+note.UpdatedAt.Format(timestampFormat))
+	}
+
+	if len(note.Tags) > 0 {
+		bits = append(bits, formatTags(note.Tags, options))
+	}
+
+	if options.Detailed && len(note.Meta) > 0 {
+		bits = append(bits, strings.Join(models.FormatMeta(note.Meta), ", "))
+	}
+
+	fmt.Println(strings.Join(bits, " | "))
+
+	if len(note.Title) > 0 {
+		titlePrinter := color.New(color.Bold)
+		fmt.Println(formatCheckbox(note) + titlePrinter.Sprint(note.Title))
+	}
+
+	contentString := note.Content
+
+	if options.SearchContent != "" {
+		printer := color.New(color.Bold, color.Underline)
+
+		if options.Pretty {
+			printer.Add(color.FgYellow)
+		}
+
+		contentString = highlightNeedle(note.Content, options.SearchContent, printer)
+	} else if highlights := findHighlights(note.Content, options); len(highlights) > 0 {
+		printer := color.New(color.Bold, color.Underline)
+
+		if options.Pretty {
+			printer.Add(color.FgYellow)
+		}
+
+		contentString = highlightNeedles(note.Content, highlights, printer)
+	}
+
+	if len(note.Title) == 0 {
+		contentString = formatCheckbox(note) + contentString
 	}
+
+	fmt.Println(contentString)
+	fmt.Println()
 }
 
-func (printer printer) PrintNote(note *models.Note, options Options) {
-	bits := make([]string, 0, 4)
+// Print a note on a single line, showing its title rather than its content.
+func (printer printer) printNoteLine(note *models.Note, options Options) {
+	bits := printer.formatNoteHeader(note, options)
+
+	title := note.GetTitle()
+	if runes := []rune(title); len(runes) > oneLineTitleLength {
+		title = string(runes[:oneLineTitleLength]) + "..."
+	}
+	bits = append(bits, formatCheckbox(note)+title)
+
+	if due := printer.formatDue(note, options); len(due) > 0 {
+		bits = append(bits, due)
+	}
+
+	if len(note.Tags) > 0 {
+		bits = append(bits, formatTags(note.Tags, options))
+	}
+
+	fmt.Println(strings.Join(bits, " | "))
+}
 
-	timestamp := note.Timestamp.Format("Jan 02 2006 03:04 PM")
+// The timestamp, a marker when pinned, and ID when detailed, which lead every printed note.
+func (printer printer) formatNoteHeader(note *models.Note, options Options) []string {
+	bits := make([]string, 0, 5)
+
+	timestamp := note.Timestamp.Format(timestampFormat)
 	if options.Pretty {
 		timestamp = color.MagentaString(timestamp)
 	}
 	bits = append(bits, timestamp)
 
+	if note.Pinned {
+		pinned := "pinned"
+		if options.Pretty {
+			pinned = color.YellowString(pinned)
+		}
+
+		bits = append(bits, pinned)
+	}
+
 	if options.Detailed {
 		noteId := note.ID
 
@@ -54,54 +165,78 @@ func (printer printer) PrintNote(note *models.Note, options Options) {
 		bits = append(bits, noteId)
 	}
 
-	if len(note.Tags) > 0 {
-		// Bold and underline any matching tags.
-		tagsString := ""
-		if len(options.SearchTags) > 0 {
-			searchTagMap := make(map[string]bool)
-			for _, searchTag := range options.SearchTags {
-				searchTagMap[searchTag] = true
-			}
+	return bits
+}
 
-			processedTags := make([]string, 0, len(note.Tags))
-			printer := color.New(color.Bold, color.Underline)
-			bluePrinter := color.New(color.FgBlue)
-
-			for _, noteTag := range note.Tags {
-				if _, exists := searchTagMap[noteTag]; exists {
-					highlightedTag := printer.Sprint(noteTag)
-					if options.Pretty {
-						highlightedTag = bluePrinter.Sprint(highlightedTag)
-					}
-
-					processedTags = append(processedTags, highlightedTag)
-				} else {
-					processedTags = append(processedTags, noteTag)
-				}
-			}
+// A checkbox showing a task's status, empty for notes which aren't tasks.
+func formatCheckbox(note *models.Note) string {
+	switch note.Status {
+	case models.StatusOpen:
+		return "[ ] "
+	case models.StatusDone:
+		return "[x] "
+	case models.StatusCancelled:
+		return "[-] "
+	}
 
-			tagsString = strings.Join(processedTags, ", ")
-		} else {
-			tagsString = strings.Join(note.Tags, ", ")
+	return ""
+}
+
+// Describe when a note is due, or when it was completed. Completion is only shown for
+// tasks without a due date when detailed.
+func (printer printer) formatDue(note *models.Note, options Options) string {
+	if !note.CompletedAt.IsZero() && (!note.Due.IsZero() || options.Detailed) {
+		closed := "done "
+		if note.Status == models.StatusCancelled {
+			closed = "cancelled "
 		}
 
-		bits = append(bits, tagsString)
+		return closed + note.CompletedAt.Format(timestampFormat)
 	}
 
-	fmt.Println(strings.Join(bits, " | "))
+	if note.Due.IsZero() || !note.CompletedAt.IsZero() {
+		return ""
+	}
 
-	contentString := note.Content
-
-	if options.SearchContent != "" {
-		printer := color.New(color.Bold, color.Underline)
+	due := "due " + note.Due.Format(timestampFormat)
 
+	if note.IsOverdue(printer.now()) {
+		due = "overdue, " + due
 		if options.Pretty {
-			printer.Add(color.FgYellow)
+			due = color.RedString(due)
 		}
+	}
 
-		contentString = highlightNeedle(note.Content, options.SearchContent, printer)
+	return due
+}
+
+// Join tags for printing, bolding and underlining any which were searched for.
+func formatTags(tags []string, options Options) string {
+	if len(options.SearchTags) == 0 {
+		return strings.Join(tags, ", ")
 	}
 
-	fmt.Println(contentString)
-	fmt.Println()
+	searchTagMap := make(map[string]bool)
+	for _, searchTag := range options.SearchTags {
+		searchTagMap[searchTag] = true
+	}
+
+	processedTags := make([]string, 0, len(tags))
+	printer := color.New(color.Bold, color.Underline)
+	bluePrinter := color.New(color.FgBlue)
+
+	for _, noteTag := range tags {
+		if _, exists := searchTagMap[noteTag]; exists {
+			highlightedTag := printer.Sprint(noteTag)
+			if options.Pretty {
+				highlightedTag = bluePrinter.Sprint(highlightedTag)
+			}
+
+			processedTags = append(processedTags, highlightedTag)
+		} else {
+			processedTags = append(processedTags, noteTag)
+		}
+	}
+
+	return strings.Join(processedTags, ", ")
 }
@@ -0,0 +1,23 @@
+package output
+
+import (
+	"github.com/ricanontherun/short-form/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPrinter_FormatDue(t *testing.T) {
+	now := time.Date(2019, 12, 8, 14, 0, 0, 0, time.Local)
+	printer := printer{now: func() time.Time { return now }}
+
+	note := models.NewNote(nil, "renew the certificates")
+	note.Due = now.Add(-time.Hour)
+	assert.EqualValues(t, "overdue, due Dec 08 2019 01:00 PM", printer.formatDue(&note, Options{}))
+
+	note.Due = now.Add(time.Hour)
+	assert.EqualValues(t, "due Dec 08 2019 03:00 PM", printer.formatDue(&note, Options{}))
+
+	note.CompletedAt = now
+	assert.EqualValues(t, "done Dec 08 2019 02:00 PM", printer.formatDue(&note, Options{}))
+}
@@ -0,0 +1,206 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ricanontherun/short-form/models"
+	"strings"
+	"time"
+)
+
+// Formats a report can be printed in.
+const (
+	ReportFormatText     = "text"
+	ReportFormatJSON     = "json"
+	ReportFormatMarkdown = "markdown"
+)
+
+var ErrInvalidReportFormat = errors.New("invalid report format, expected text, json or markdown")
+
+// Width of the longest bar in a chart.
+const chartWidth = 40
+
+// Print a report as bar charts, JSON or Markdown tables.
+func (printer printer) PrintReport(report *models.Report, format string) error {
+	switch format {
+	case ReportFormatText, "":
+		fmt.Print(formatReportText(report))
+	case ReportFormatJSON:
+		encoded, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(encoded))
+	case ReportFormatMarkdown, "md":
+		fmt.Print(formatReportMarkdown(report))
+	default:
+		return ErrInvalidReportFormat
+	}
+
+	return nil
+}
+
+func formatReportText(report *models.Report) string {
+	var builder strings.Builder
+
+	builder.WriteString(formatReportPeriod(report) + "\n\n")
+	builder.WriteString(fmt.Sprintf("notes:          %d\n", report.NoteCount))
+	builder.WriteString(fmt.Sprintf("words:          %d\n", report.WordCount))
+	builder.WriteString(fmt.Sprintf("longest streak: %s\n", formatStreak(report.LongestStreak)))
+
+	if len(report.TopTags) > 0 {
+		builder.WriteString(fmt.Sprintf("top tags:       %s\n", formatTagCounts(report.TopTags)))
+	}
+
+	if report.NoteCount == 0 {
+		return builder.String()
+	}
+
+	days := make([]chartRow, 0, len(report.Days))
+	for _, day := range report.Days {
+		days = append(days, chartRow{formatReportDay(day.Day, "Mon Jan 02"), day.Count})
+	}
+	builder.WriteString("\nnotes per day\n" + formatChart(days))
+
+	builder.WriteString("\nnotes per hour\n" + formatChart(hourRows(report)))
+
+	if len(report.Tags) > 0 {
+		tags := make([]chartRow, 0, len(report.Tags))
+		for _, tag := range report.Tags {
+			tags = append(tags, chartRow{tag.Tag, tag.Count})
+		}
+		builder.WriteString("\nnotes per tag\n" + formatChart(tags))
+	}
+
+	return builder.String()
+}
+
+func formatReportMarkdown(report *models.Report) string {
+	var builder strings.Builder
+
+	builder.WriteString("## " + formatReportPeriod(report) + "\n\n")
+	builder.WriteString(fmt.Sprintf("- **Notes:** %d\n", report.NoteCount))
+	builder.WriteString(fmt.Sprintf("- **Words:** %d\n", report.WordCount))
+	builder.WriteString(fmt.Sprintf("- **Longest streak:** %s\n", formatStreak(report.LongestStreak)))
+
+	if len(report.TopTags) > 0 {
+		builder.WriteString(fmt.Sprintf("- **Top tags:** %s\n", formatTagCounts(report.TopTags)))
+	}
+
+	if report.NoteCount == 0 {
+		return builder.String()
+	}
+
+	builder.WriteString("\n### Notes per day\n\n| Day | Notes |\n| --- | ---: |\n")
+	for _, day := range report.Days {
+		builder.WriteString(fmt.Sprintf("| %s | %d |\n", formatReportDay(day.Day, "Mon Jan 02"), day.Count))
+	}
+
+	builder.WriteString("\n### Notes per hour\n\n| Hour | Notes |\n| --- | ---: |\n")
+	for _, row := range hourRows(report) {
+		builder.WriteString(fmt.Sprintf("| %s | %d |\n", row.label, row.count))
+	}
+
+	if len(report.Tags) > 0 {
+		builder.WriteString("\n### Notes per tag\n\n| Tag | Notes |\n| --- | ---: |\n")
+		for _, tag := range report.Tags {
+			builder.WriteString(fmt.Sprintf("| %s | %d |\n", tag.Tag, tag.Count))
+		}
+	}
+
+	return builder.String()
+}
+
+type chartRow struct {
+	label string
+	count int
+}
+
+// Draw rows as a bar chart, scaled so the largest count fills the chart.
+func formatChart(rows []chartRow) string {
+	labelWidth, largest := 0, 0
+	for _, row := range rows {
+		if len(row.label) > labelWidth {
+			labelWidth = len(row.label)
+		}
+
+		if row.count > largest {
+			largest = row.count
+		}
+	}
+
+	var builder strings.Builder
+	for _, row := range rows {
+		bar := 0
+		if largest > 0 {
+			bar = row.count * chartWidth / largest
+		}
+
+		// Any notes at all get a bar.
+		if bar == 0 && row.count > 0 {
+			bar = 1
+		}
+
+		builder.WriteString(fmt.Sprintf("%-*s | ", labelWidth, row.label))
+		if bar > 0 {
+			builder.WriteString(strings.Repeat("#", bar) + " ")
+		}
+		builder.WriteString(fmt.Sprintf("%d\n", row.count))
+	}
+
+	return builder.String()
+}
+
+// The hours from the first to the last with notes.
+func hourRows(report *models.Report) []chartRow {
+	first, last := -1, -1
+	for hour, count := range report.Hours {
+		if count > 0 {
+			if first == -1 {
+				first = hour
+			}
+			last = hour
+		}
+	}
+
+	var rows []chartRow
+	for hour := first; hour != -1 && hour <= last; hour++ {
+		rows = append(rows, chartRow{fmt.Sprintf("%02d:00", hour), report.Hours[hour]})
+	}
+
+	return rows
+}
+
+func formatReportPeriod(report *models.Report) string {
+	return report.Since.Format("Jan 02 2006") + " - " + report.Until.Format("Jan 02 2006")
+}
+
+func formatReportDay(day string, layout string) string {
+	if date, err := time.Parse(models.DailyDateFormat, day); err == nil {
+		return date.Format(layout)
+	}
+
+	return day
+}
+
+func formatStreak(streak models.Streak) string {
+	switch streak.Days {
+	case 0:
+		return "none"
+	case 1:
+		return "1 day, " + formatReportDay(streak.Start, "Jan 02")
+	}
+
+	return fmt.Sprintf("%d days, %s - %s", streak.Days, formatReportDay(streak.Start, "Jan 02"), formatReportDay(streak.End, "Jan 02"))
+}
+
+func formatTagCounts(tags []models.TagCount) string {
+	formatted := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		formatted = append(formatted, fmt.Sprintf("%s (%d)", tag.Tag, tag.Count))
+	}
+
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,278 @@
+package publish
+
+import (
+	"github.com/ricanontherun/short-form/models"
+	"html/template"
+	"sort"
+)
+
+const (
+	dateFormat = "2006-01-02"
+	dayFormat  = "Monday, January 2 2006"
+)
+
+// The data pages are rendered with, only some of which is set for each kind of page.
+type page struct {
+	// Path from the page back to the top of the site, e.g ../
+	Root string
+
+	Title string
+
+	// Notes by the day they were written, newest first.
+	Days []day
+
+	Tags []models.TagCount
+
+	Note        *models.Note
+	Content     template.HTML
+	Attachments []attachmentLink
+}
+
+// A note's attachment, published beside its page.
+type attachmentLink struct {
+	Name string
+	Size string
+	URL  string
+}
+
+type day struct {
+	Name  string
+	Notes []*models.Note
+}
+
+type pageBuilder struct {
+	notes    []*models.Note
+	tagNames []string
+	tagCount map[string]int
+}
+
+// Notes are expected newest first.
+func newPageBuilder(notes []*models.Note) pageBuilder {
+	builder := pageBuilder{notes: notes, tagCount: make(map[string]int)}
+
+	for _, note := range notes {
+		for _, tag := range note.Tags {
+			if builder.tagCount[tag] == 0 {
+				builder.tagNames = append(builder.tagNames, tag)
+			}
+
+			builder.tagCount[tag]++
+		}
+	}
+
+	sort.Strings(builder.tagNames)
+	return builder
+}
+
+func (builder pageBuilder) index() page {
+	return page{Title: "Journal", Days: groupByDay(builder.notes)}
+}
+
+func (builder pageBuilder) tags() page {
+	counts := make([]models.TagCount, 0, len(builder.tagNames))
+	for _, tag := range builder.tagNames {
+		counts = append(counts, models.TagCount{Tag: tag, Count: builder.tagCount[tag]})
+	}
+
+	return page{Title: "Tags", Tags: counts}
+}
+
+// A tag's page has the notes beneath it too, e.g work has work/infra's notes.
+func (builder pageBuilder) tag(tag string) page {
+	var notes []*models.Note
+
+	for _, note := range builder.notes {
+		if hasTagWithin(note.Tags, []string{tag}) {
+			notes = append(notes, note)
+		}
+	}
+
+	return page{Title: "#" + tag, Days: groupByDay(notes)}
+}
+
+func (builder pageBuilder) note(note *models.Note) page {
+	return page{
+		Title:   noteTitle(note),
+		Note:    note,
+		Content: renderMarkdown(resolveLinks(note.Content, builder.notes)),
+	}
+}
+
+func groupByDay(notes []*models.Note) []day {
+	var days []day
+
+	for _, note := range notes {
+		name := note.Timestamp.Format(dayFormat)
+
+		if len(days) == 0 || days[len(days)-1].Name != name {
+			days = append(days, day{Name: name})
+		}
+
+		days[len(days)-1].Notes = append(days[len(days)-1].Notes, note)
+	}
+
+	return days
+}
+
+var pageTemplates = template.Must(template.New("site").Funcs(template.FuncMap{
+	"noteURL": func(note *models.Note) string { return pageURL(notePath(note)) },
+	"tagURL":  func(tag string) string { return pageURL(tagPath(tag)) },
+	"title":   noteTitle,
+}).Parse(`
+{{- define "header" -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body>
+<nav><a href="{{.Root}}index.html">Journal</a> <a href="{{.Root}}tags.html">Tags</a></nav>
+<main>
+{{end}}
+
+{{- define "footer" -}}
+</main>
+</body>
+</html>
+{{end}}
+
+{{- define "tag-links" -}}
+{{range .Note.Tags}} <a class="tag" href="{{$.Root}}{{tagURL .}}">#{{.}}</a>{{end}}
+{{- end}}
+
+{{- define "days" -}}
+{{- $root := .Root}}
+{{- range .Days}}
+<h2>{{.Name}}</h2>
+<ul class="notes">
+{{- range .Notes}}
+<li><span class="time">{{.Timestamp.Format "15:04"}}</span> <a href="{{$root}}{{noteURL .}}">{{title .}}</a>
+{{- range .Tags}} <a class="tag" href="{{$root}}{{tagURL .}}">#{{.}}</a>{{end}}</li>
+{{- end}}
+</ul>
+{{- else}}
+<p>No notes.</p>
+{{- end}}
+{{end}}
+
+{{- define "index" -}}
+{{template "header" .}}
+<h1>{{.Title}}</h1>
+<input id="search" type="search" placeholder="Search notes" autocomplete="off">
+<ul id="results" class="notes"></ul>
+<div id="days">
+{{- template "days" .}}
+</div>
+<script src="{{.Root}}search-index.js"></script>
+<script src="{{.Root}}search.js"></script>
+{{template "footer" .}}
+{{- end}}
+
+{{- define "tags" -}}
+{{template "header" .}}
+<h1>{{.Title}}</h1>
+<ul class="tags">
+{{- range .Tags}}
+<li><a class="tag" href="{{$.Root}}{{tagURL .Tag}}">#{{.Tag}}</a> {{.Count}}</li>
+{{- end}}
+</ul>
+{{template "footer" .}}
+{{- end}}
+
+{{- define "tag" -}}
+{{template "header" .}}
+<h1>{{.Title}}</h1>
+{{- template "days" .}}
+{{template "footer" .}}
+{{- end}}
+
+{{- define "note" -}}
+{{template "header" .}}
+<article>
+<h1>{{.Title}}</h1>
+<p class="details"><time datetime="{{.Note.Timestamp.Format "2006-01-02T15:04:05Z07:00"}}">{{.Note.Timestamp.Format "Monday, January 2 2006 15:04"}}</time>
+{{- template "tag-links" .}}</p>
+{{- if .Note.Meta}}
+<dl class="meta">
+{{- range $key, $value := .Note.Meta}}
+<dt>{{$key}}</dt><dd>{{$value}}</dd>
+{{- end}}
+</dl>
+{{- end}}
+<div class="content">
+{{.Content}}
+</div>
+{{- if .Attachments}}
+<h2>Attachments</h2>
+<ul class="attachments">
+{{- range .Attachments}}
+<li><a href="{{$.Root}}{{.URL}}">{{.Name}}</a> {{.Size}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</article>
+{{template "footer" .}}
+{{- end}}
+`))
+
+const stylesheet = `body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.5; color: #222; margin: 0; }
+nav { padding: 0.75em 1.5em; border-bottom: 1px solid #ddd; }
+nav a { margin-right: 1em; font-weight: bold; }
+main { max-width: 48em; margin: 0 auto; padding: 1em 1.5em; }
+a { color: #0550ae; text-decoration: none; }
+a:hover { text-decoration: underline; }
+h2 { font-size: 1.1em; margin-top: 1.5em; color: #555; }
+ul.notes, ul.tags { list-style: none; padding: 0; }
+ul.notes li { margin: 0.25em 0; }
+.time, .details { color: #777; }
+.tag { font-size: 0.9em; margin-left: 0.25em; }
+#search { width: 100%; padding: 0.5em; font-size: 1em; box-sizing: border-box; }
+dl.meta dt { float: left; clear: left; font-weight: bold; margin-right: 0.5em; }
+dl.meta dt::after { content: ":"; }
+pre { background: #f6f8fa; padding: 0.75em; overflow-x: auto; }
+`
+
+// Searches the notes in search-index.js for every word typed, hiding the notes by day while searching.
+const searchScript = `(function () {
+  var input = document.getElementById("search");
+  var results = document.getElementById("results");
+  var days = document.getElementById("days");
+
+  input.addEventListener("input", function () {
+    var words = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    results.innerHTML = "";
+    days.hidden = words.length > 0;
+
+    if (words.length === 0) {
+      return;
+    }
+
+    searchIndex.forEach(function (note) {
+      var text = (note.title + " " + note.content + " " + note.tags.join(" ")).toLowerCase();
+      var matches = words.every(function (word) { return text.indexOf(word) !== -1; });
+
+      if (matches) {
+        var item = document.createElement("li");
+        var date = document.createElement("span");
+        var link = document.createElement("a");
+
+        date.className = "time";
+        date.textContent = note.date + " ";
+        link.href = note.url;
+        link.textContent = note.title;
+
+        item.appendChild(date);
+        item.appendChild(link);
+        results.appendChild(item);
+      }
+    });
+
+    if (results.children.length === 0) {
+      results.innerHTML = "<li>No notes found.</li>";
+    }
+  });
+})();
+`
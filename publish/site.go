@@ -0,0 +1,338 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/ricanontherun/short-form/utils"
+	"github.com/russross/blackfriday/v2"
+	"html/template"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Marks a directory as a published site, so it can be published to again.
+const markerName = ".sf-site"
+
+// Directories which are removed and written again each time a site is published.
+const (
+	notesDirectory       = "notes"
+	tagsDirectory        = "tags"
+	attachmentsDirectory = "attachments"
+)
+
+var ErrNotASite = errors.New("output directory isn't empty and wasn't created by sf publish")
+
+// AttachmentReader reads the files attached to notes, see repository.Attacher.
+type AttachmentReader interface {
+	ListAttachments(ctx context.Context, noteId string) ([]models.Attachment, error)
+	ReadAttachment(ctx context.Context, noteId string, name string) (*models.Attachment, error)
+}
+
+// Site is a static HTML site of notes, browsable offline.
+type Site struct {
+	dir string
+
+	// Reads the attachments published with each note, when set.
+	ctx         context.Context
+	attachments AttachmentReader
+}
+
+func NewSite(dir string) Site {
+	return Site{dir: dir}
+}
+
+// WithAttachments publishes each note's attachments, linked from the note's page.
+func (site Site) WithAttachments(ctx context.Context, reader AttachmentReader) Site {
+	site.ctx = ctx
+	site.attachments = reader
+	return site
+}
+
+// WithoutPrivate removes notes which have any private tag, or a tag beneath one.
+func WithoutPrivate(notes []*models.Note, privateTags []string) []*models.Note {
+	if len(privateTags) == 0 {
+		return notes
+	}
+
+	public := make([]*models.Note, 0, len(notes))
+
+	for _, note := range notes {
+		if !hasTagWithin(note.Tags, privateTags) {
+			public = append(public, note)
+		}
+	}
+
+	return public
+}
+
+func hasTagWithin(tags []string, roots []string) bool {
+	for _, tag := range tags {
+		for _, root := range roots {
+			if models.TagWithin(tag, root) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Write the site for notes: an index by date, a page per tag and note, and a search index.
+// Pages of notes which are no longer published, e.g as they've since been made private, are removed.
+func (site Site) Write(notes []*models.Note) error {
+	if err := site.prepare(); err != nil {
+		return err
+	}
+
+	sorted := append([]*models.Note{}, notes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.After(sorted[j].Timestamp)
+	})
+
+	pages := newPageBuilder(sorted)
+
+	if err := site.writePage("index.html", "index", pages.index()); err != nil {
+		return err
+	}
+
+	if err := site.writePage("tags.html", "tags", pages.tags()); err != nil {
+		return err
+	}
+
+	for _, tag := range pages.tagNames {
+		if err := site.writePage(tagPath(tag), "tag", pages.tag(tag)); err != nil {
+			return err
+		}
+	}
+
+	for _, note := range sorted {
+		notePage := pages.note(note)
+
+		attachments, err := site.writeAttachments(note)
+		if err != nil {
+			return err
+		}
+		notePage.Attachments = attachments
+
+		if err := site.writePage(notePath(note), "note", notePage); err != nil {
+			return err
+		}
+	}
+
+	if err := site.writeSearchIndex(sorted); err != nil {
+		return err
+	}
+
+	if err := site.writeFile("style.css", []byte(stylesheet)); err != nil {
+		return err
+	}
+
+	return site.writeFile("search.js", []byte(searchScript))
+}
+
+// Create the site's directory, or clear out the pages of a site published before.
+// Directories which aren't empty are only written to when they're a published site.
+func (site Site) prepare() error {
+	entries, err := ioutil.ReadDir(site.dir)
+	if os.IsNotExist(err) {
+		if err := os.MkdirAll(site.dir, 0755); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if len(entries) > 0 {
+		if _, err := os.Stat(filepath.Join(site.dir, markerName)); os.IsNotExist(err) {
+			return ErrNotASite
+		} else if err != nil {
+			return err
+		}
+	}
+
+	for _, directory := range []string{notesDirectory, tagsDirectory, attachmentsDirectory} {
+		if err := os.RemoveAll(filepath.Join(site.dir, directory)); err != nil {
+			return err
+		}
+	}
+
+	return site.writeFile(markerName, nil)
+}
+
+func (site Site) writeFile(name string, contents []byte) error {
+	path := filepath.Join(site.dir, filepath.FromSlash(name))
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, contents, 0644)
+}
+
+func (site Site) writePage(name string, layout string, data page) error {
+	// Links are relative, so the site can be opened straight from disk.
+	data.Root = strings.Repeat("../", strings.Count(name, "/"))
+
+	var buffer bytes.Buffer
+	if err := pageTemplates.ExecuteTemplate(&buffer, layout, data); err != nil {
+		return err
+	}
+
+	return site.writeFile(name, buffer.Bytes())
+}
+
+// Write a note's attachments, returning links to them for the note's page.
+func (site Site) writeAttachments(note *models.Note) ([]attachmentLink, error) {
+	if site.attachments == nil {
+		return nil, nil
+	}
+
+	attachments, err := site.attachments.ListAttachments(site.ctx, note.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]attachmentLink, 0, len(attachments))
+	for _, listed := range attachments {
+		// Listing leaves out the data.
+		attachment, err := site.attachments.ReadAttachment(site.ctx, note.ID, listed.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		path := attachmentPath(note, attachment.Name)
+		if err := site.writeFile(path, attachment.Data); err != nil {
+			return nil, err
+		}
+
+		links = append(links, attachmentLink{
+			Name: attachment.Name,
+			Size: utils.FormatBytes(attachment.Size),
+			URL:  pageURL(path),
+		})
+	}
+
+	return links, nil
+}
+
+type searchEntry struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Date    string   `json:"date"`
+	Tags    []string `json:"tags"`
+	Content string   `json:"content"`
+	URL     string   `json:"url"`
+}
+
+// Write the search index as JSON, and as a script which search.js reads, as browsers
+// don't allow pages opened from disk to fetch other files.
+func (site Site) writeSearchIndex(notes []*models.Note) error {
+	entries := make([]searchEntry, 0, len(notes))
+
+	for _, note := range notes {
+		entries = append(entries, searchEntry{
+			ID:      note.ID,
+			Title:   noteTitle(note),
+			Date:    note.Timestamp.Format(dateFormat),
+			Tags:    note.Tags,
+			Content: note.Content,
+			URL:     pageURL(notePath(note)),
+		})
+	}
+
+	index, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	if err := site.writeFile("search.json", index); err != nil {
+		return err
+	}
+
+	return site.writeFile("search-index.js", append(append([]byte("var searchIndex = "), index...), ";\n"...))
+}
+
+func notePath(note *models.Note) string {
+	return notesDirectory + "/" + pathSegment(note.ID) + ".html"
+}
+
+// Attachments are kept in a directory per note, e.g attachments/ID/error.png.
+func attachmentPath(note *models.Note, name string) string {
+	return attachmentsDirectory + "/" + pathSegment(note.ID) + "/" + pathSegment(name)
+}
+
+// Hierarchical tags are nested, e.g work/infra is at tags/work/infra.html.
+func tagPath(tag string) string {
+	segments := strings.Split(tag, models.TagSeparator)
+	for i, segment := range segments {
+		segments[i] = pathSegment(segment)
+	}
+
+	return tagsDirectory + "/" + strings.Join(segments, "/") + ".html"
+}
+
+// Escape a tag or ID for use as a file name, so it can't leave the site's directory.
+func pathSegment(name string) string {
+	escaped := url.PathEscape(name)
+
+	if strings.HasPrefix(escaped, ".") || len(escaped) == 0 {
+		escaped = "%2E" + strings.TrimPrefix(escaped, ".")
+	}
+
+	return escaped
+}
+
+// The URL of a page written to path, whose file name may itself be escaped.
+func pageURL(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// A note's title, without the # of a Markdown heading when it's the first line of the content.
+func noteTitle(note *models.Note) string {
+	return strings.TrimSpace(strings.TrimLeft(note.GetTitle(), "#"))
+}
+
+// Render note content as Markdown. Raw HTML is left out, so a shared site can't run scripts.
+func renderMarkdown(content string) template.HTML {
+	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
+		Flags: blackfriday.CommonHTMLFlags | blackfriday.SkipHTML | blackfriday.Safelink,
+	})
+
+	return template.HTML(blackfriday.Run([]byte(content), blackfriday.WithRenderer(renderer)))
+}
+
+// Replace links to published notes with Markdown links to their pages, relative to the other note
+// pages. Links to notes which aren't published are left as they are, so private notes aren't given
+// away by their titles.
+func resolveLinks(content string, notes []*models.Note) string {
+	return models.ReplaceLinks(content, func(target string) (string, bool) {
+		var linked *models.Note
+		for _, note := range notes {
+			if models.LinksTo(target, note.ID) {
+				if linked != nil {
+					return "", false
+				}
+
+				linked = note
+			}
+		}
+
+		if linked == nil {
+			return "", false
+		}
+
+		title := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(noteTitle(linked))
+		// Relative links need ./ to be considered safe by the Markdown renderer.
+		return "[" + title + "](./" + url.PathEscape(pathSegment(linked.ID)) + ".html)", true
+	})
+}
@@ -0,0 +1,144 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newNote(id string, content string, day int, tags ...string) *models.Note {
+	note := models.NewNote(tags, content)
+	note.ID = id
+	note.Timestamp = time.Date(2019, 12, day, 9, 0, 0, 0, time.Local)
+	return &note
+}
+
+func readFile(t *testing.T, path string) string {
+	contents, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	return string(contents)
+}
+
+func TestWithoutPrivate(t *testing.T) {
+	public := newNote("a1", "public", 8, "work")
+	private := newNote("b2", "private", 8, "journal")
+	beneath := newNote("c3", "beneath", 8, "work", "journal/health")
+	notes := []*models.Note{public, private, beneath}
+
+	assert.EqualValues(t, notes, WithoutPrivate(notes, nil))
+	assert.EqualValues(t, []*models.Note{public}, WithoutPrivate(notes, []string{"journal"}))
+}
+
+func TestSite_Write(t *testing.T) {
+	directory, err := ioutil.TempDir("", "sf-site")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+
+	out := filepath.Join(directory, "site")
+	first := newNote("aaaaaa11", "# Deploys\n\nShipped *v2*<script>alert(1)</script>", 8, "work/infra")
+	second := newNote("bbbbbb22", "Follow up on [[aaaaaa]] and [[cccccc]]", 9, "work")
+
+	assert.Nil(t, NewSite(out).Write([]*models.Note{first, second}))
+
+	index := readFile(t, filepath.Join(out, "index.html"))
+	assert.Contains(t, index, "<h2>Monday, December 9 2019</h2>")
+	assert.Contains(t, index, `href="notes/bbbbbb22.html"`)
+	assert.Contains(t, index, `href="tags/work/infra.html"`)
+	assert.Contains(t, index, `src="search-index.js"`)
+
+	// Note content is Markdown, without raw HTML, and links to published notes lead to their pages.
+	page := readFile(t, filepath.Join(out, "notes", "aaaaaa11.html"))
+	assert.Contains(t, page, `href="../style.css"`)
+	assert.Contains(t, page, "<h1>Deploys</h1>")
+	assert.Contains(t, page, "<em>v2</em>")
+	assert.NotContains(t, page, "<script>")
+
+	page = readFile(t, filepath.Join(out, "notes", "bbbbbb22.html"))
+	assert.Contains(t, page, `<a href="./aaaaaa11.html">Deploys</a>`)
+	assert.Contains(t, page, "[[cccccc]]")
+
+	// Tags have the notes beneath them.
+	page = readFile(t, filepath.Join(out, "tags", "work.html"))
+	assert.Contains(t, page, `href="../notes/aaaaaa11.html"`)
+	assert.Contains(t, page, `href="../notes/bbbbbb22.html"`)
+	page = readFile(t, filepath.Join(out, "tags", "work", "infra.html"))
+	assert.Contains(t, page, `href="../../notes/aaaaaa11.html"`)
+	assert.NotContains(t, page, "bbbbbb22")
+
+	var entries []searchEntry
+	assert.Nil(t, json.Unmarshal([]byte(readFile(t, filepath.Join(out, "search.json"))), &entries))
+	assert.Len(t, entries, 2)
+	assert.EqualValues(t, "bbbbbb22", entries[0].ID)
+	assert.EqualValues(t, "notes/bbbbbb22.html", entries[0].URL)
+	assert.EqualValues(t, "2019-12-09", entries[0].Date)
+
+	// Publishing again removes the pages of notes which are no longer published.
+	assert.Nil(t, NewSite(out).Write([]*models.Note{second}))
+	_, err = os.Stat(filepath.Join(out, "notes", "aaaaaa11.html"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(out, "tags", "work", "infra.html"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Other directories are left alone.
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(directory, "notes.txt"), []byte("mine"), 0644))
+	assert.EqualValues(t, ErrNotASite, NewSite(directory).Write([]*models.Note{second}))
+}
+
+// Attachments kept in memory, by note ID.
+type attachmentsByNote map[string][]models.Attachment
+
+func (attachments attachmentsByNote) ListAttachments(ctx context.Context, noteId string) ([]models.Attachment, error) {
+	listed := make([]models.Attachment, 0)
+	for _, attachment := range attachments[noteId] {
+		attachment.Data = nil
+		listed = append(listed, attachment)
+	}
+
+	return listed, nil
+}
+
+func (attachments attachmentsByNote) ReadAttachment(ctx context.Context, noteId string, name string) (*models.Attachment, error) {
+	for _, attachment := range attachments[noteId] {
+		if attachment.Name == name {
+			return &attachment, nil
+		}
+	}
+
+	return nil, os.ErrNotExist
+}
+
+func TestSite_WriteAttachments(t *testing.T) {
+	directory, err := ioutil.TempDir("", "sf-site")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+
+	out := filepath.Join(directory, "site")
+	note := newNote("aaaaaa11", "Login fails after the upgrade", 8)
+	plain := newNote("bbbbbb22", "No attachments", 9)
+	attachments := attachmentsByNote{note.ID: {models.NewAttachment(note.ID, "app.log", []byte("panic: oops\n"))}}
+
+	site := NewSite(out).WithAttachments(context.Background(), attachments)
+	assert.Nil(t, site.Write([]*models.Note{note, plain}))
+
+	assert.EqualValues(t, "panic: oops\n", readFile(t, filepath.Join(out, "attachments", note.ID, "app.log")))
+	assert.Contains(t, readFile(t, filepath.Join(out, "notes", note.ID+".html")), `<a href="../attachments/aaaaaa11/app.log">app.log</a>`)
+	assert.NotContains(t, readFile(t, filepath.Join(out, "notes", plain.ID+".html")), "Attachments")
+
+	// Attachments of notes which are no longer published are removed.
+	assert.Nil(t, site.Write([]*models.Note{plain}))
+	_, err = os.Stat(filepath.Join(out, "attachments", note.ID))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestTagPath(t *testing.T) {
+	assert.EqualValues(t, "tags/work/infra.html", tagPath("work/infra"))
+	assert.EqualValues(t, "tags/%2E./%2E./etc.html", tagPath("../../etc"))
+	assert.EqualValues(t, "tags/c%3F.html", tagPath("c?"))
+	assert.EqualValues(t, "tags/c%253F.html", pageURL(tagPath("c?")))
+}
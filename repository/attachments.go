@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"github.com/ricanontherun/short-form/models"
+)
+
+// Attacher is implemented by repositories which can store files alongside notes.
+type Attacher interface {
+	// Store files with an existing note. Either every file is stored, or none are.
+	WriteAttachments(ctx context.Context, attachments []models.Attachment) error
+
+	// Write a note and its attachments together, so a failed attachment doesn't leave the note behind.
+	WriteNoteWithAttachments(ctx context.Context, note models.Note, attachments []models.Attachment) error
+
+	// List a note's attachments, without their content.
+	ListAttachments(ctx context.Context, noteId string) ([]models.Attachment, error)
+
+	// Fetch a single attachment, with its content.
+	ReadAttachment(ctx context.Context, noteId string, name string) (*models.Attachment, error)
+}
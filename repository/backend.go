@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/ricanontherun/short-form/database"
+	"sort"
+	"strings"
+)
+
+const (
+	BackendSqlite = "sqlite"
+	BackendFiles  = "files"
+	BackendMemory = "memory"
+)
+
+// StorageConfig is what storage backends are configured from.
+type StorageConfig interface {
+	GetDatabasePath() string
+	GetConnectionOptions() database.Options
+	GetFilesPath() string
+}
+
+// Backend opens a repository using a storage configuration.
+type Backend func(config StorageConfig) (Repository, error)
+
+var backends = make(map[string]Backend)
+
+// RegisterBackend makes a storage backend available by name.
+func RegisterBackend(name string, backend Backend) {
+	backends[name] = backend
+}
+
+// Backends returns the names of every registered backend, sorted.
+func Backends() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// OpenBackend opens a repository with the named storage backend.
+func OpenBackend(name string, config StorageConfig) (Repository, error) {
+	backend, exists := backends[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown storage backend '%s', expected one of: %s", name, strings.Join(Backends(), ", "))
+	}
+
+	return backend(config)
+}
+
+func init() {
+	RegisterBackend(BackendSqlite, func(config StorageConfig) (Repository, error) {
+		return NewSqlRepository(database.NewDatabase(config.GetDatabasePath(), config.GetConnectionOptions()))
+	})
+
+	RegisterBackend(BackendFiles, func(config StorageConfig) (Repository, error) {
+		return NewFilesRepository(config.GetFilesPath())
+	})
+
+	RegisterBackend(BackendMemory, func(config StorageConfig) (Repository, error) {
+		return NewMemoryRepository(), nil
+	})
+}
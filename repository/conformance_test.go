@@ -0,0 +1,714 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+	"time"
+)
+
+// repositoryFactory creates an empty repository, and a function to clean it up.
+type repositoryFactory func(t *testing.T) (Repository, func())
+
+// testRepositoryConformance runs the behaviour every Repository implementation must share.
+func testRepositoryConformance(t *testing.T, factory repositoryFactory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo Repository)
+	}{
+		{"WriteAndLookup", conformanceWriteAndLookup},
+		{"WriteDuplicate", conformanceWriteDuplicate},
+		{"SearchFilters", conformanceSearchFilters},
+		{"SearchOrder", conformanceSearchOrder},
+		{"LatestNotes", conformanceLatestNotes},
+		{"SearchNotesFunc", conformanceSearchNotesFunc},
+		{"Delete", conformanceDelete},
+		{"Update", conformanceUpdate},
+		{"Titles", conformanceTitles},
+		{"Meta", conformanceMeta},
+		{"Tag", conformanceTag},
+		{"TagTree", conformanceTagTree},
+		{"RenameTag", conformanceRenameTag},
+		{"Links", conformanceLinks},
+		{"Due", conformanceDue},
+		{"Status", conformanceStatus},
+		{"Pins", conformancePins},
+		{"Fuzzy", conformanceFuzzy},
+		{"Regex", conformanceRegex},
+		{"Cancelled", conformanceCancelled},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo, cleanup := factory(t)
+			defer cleanup()
+
+			test.test(t, repo)
+		})
+	}
+}
+
+// Write a note at a fixed point in time.
+func writeNoteAt(t *testing.T, repo Repository, timestamp time.Time, tags []string, content string) models.Note {
+	note := models.NewNote(tags, content)
+	note.Timestamp = timestamp
+
+	if err := repo.WriteNote(context.Background(), note); err != nil {
+		t.Fatal(err)
+	}
+
+	return note
+}
+
+func noteIds(notes []*models.Note) []string {
+	ids := make([]string, 0, len(notes))
+	for _, note := range notes {
+		ids = append(ids, note.ID)
+	}
+
+	return ids
+}
+
+func conformanceWriteAndLookup(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	note := writeNoteAt(t, repo, time.Now(), []string{"git", "cli"}, "git rebase -i")
+
+	found, err := repo.LookupNote(ctx, note.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, note.ID, found.ID)
+	assert.EqualValues(t, note.Content, found.Content)
+	assert.Empty(t, found.Tags)
+
+	found, err = repo.LookupNoteWithTags(ctx, note.ID)
+	assert.Nil(t, err)
+	sort.Strings(found.Tags)
+	assert.EqualValues(t, []string{"cli", "git"}, found.Tags)
+
+	_, err = repo.LookupNote(ctx, models.NewNote(nil, "").ID)
+	assert.EqualValues(t, ErrNoteNotFound, err)
+}
+
+func conformanceWriteDuplicate(t *testing.T, repo Repository) {
+	note := writeNoteAt(t, repo, time.Now(), nil, "original")
+
+	assert.EqualValues(t, ErrNoteExists, repo.WriteNote(context.Background(), note))
+}
+
+func conformanceSearchFilters(t *testing.T, repo Repository) {
+	now := time.Now()
+	today := models.GetRangeToday(now)
+	yesterday := models.GetRangeYesterday(now)
+
+	rebase := writeNoteAt(t, repo, today.From.Add(time.Hour), []string{"git", "cli"}, "git rebase -i COMMIT")
+	music := writeNoteAt(t, repo, today.From.Add(2*time.Hour), []string{"music"}, "Listen to more Coltrane")
+	old := writeNoteAt(t, repo, yesterday.From.Add(time.Hour), []string{"GIT"}, "git bisect start")
+	untagged := writeNoteAt(t, repo, yesterday.From.Add(2*time.Hour), nil, "Nothing to see here")
+
+	tests := []struct {
+		name     string
+		filters  models.SearchFilters
+		expected []string
+	}{
+		{"none", models.SearchFilters{}, []string{old.ID, untagged.ID, rebase.ID, music.ID}},
+		{"date", models.SearchFilters{DateRange: &today}, []string{rebase.ID, music.ID}},
+		{"tag ignores case", models.SearchFilters{Tags: []string{"git"}}, []string{old.ID, rebase.ID}},
+		{"any tag", models.SearchFilters{Tags: []string{"music", "cli"}}, []string{rebase.ID, music.ID}},
+		{"content ignores case", models.SearchFilters{Content: "COLTRANE"}, []string{music.ID}},
+		{"content substring", models.SearchFilters{Content: "git b"}, []string{old.ID}},
+		{"combined", models.SearchFilters{DateRange: &yesterday, Tags: []string{"git"}, Content: "bisect"}, []string{old.ID}},
+		{"no match", models.SearchFilters{Tags: []string{"missing"}}, []string{}},
+	}
+
+	for _, test := range tests {
+		notes, err := repo.SearchNotes(context.Background(), test.filters)
+		assert.Nil(t, err, test.name)
+		assert.EqualValues(t, test.expected, noteIds(notes), test.name)
+	}
+
+	// Matching on one tag still returns all of the note's tags.
+	notes, err := repo.SearchNotes(context.Background(), models.SearchFilters{Tags: []string{"cli"}})
+	assert.Nil(t, err)
+	assert.Len(t, notes, 1)
+	sort.Strings(notes[0].Tags)
+	assert.EqualValues(t, []string{"cli", "git"}, notes[0].Tags)
+}
+
+func conformanceSearchOrder(t *testing.T, repo Repository) {
+	now := time.Now()
+	third := writeNoteAt(t, repo, now, nil, "third")
+	first := writeNoteAt(t, repo, now.Add(-2*time.Hour), nil, "first")
+	second := writeNoteAt(t, repo, now.Add(-time.Hour), nil, "second")
+
+	notes, err := repo.SearchNotes(context.Background(), models.SearchFilters{})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{first.ID, second.ID, third.ID}, noteIds(notes))
+}
+
+func conformanceLatestNotes(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	now := time.Now()
+	first := writeNoteAt(t, repo, now.Add(-2*time.Hour), []string{"work"}, "first")
+	second := writeNoteAt(t, repo, now.Add(-time.Hour), nil, "second")
+	third := writeNoteAt(t, repo, now, nil, "third")
+
+	// Pinned notes aren't listed first.
+	assert.Nil(t, repo.PinNote(ctx, first.ID, true))
+
+	notes, err := repo.LatestNotes(ctx, 2)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{third.ID, second.ID}, noteIds(notes))
+
+	notes, err = repo.LatestNotes(ctx, 10)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{third.ID, second.ID, first.ID}, noteIds(notes))
+}
+
+func conformanceSearchNotesFunc(t *testing.T, repo Repository) {
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		writeNoteAt(t, repo, now.Add(time.Duration(i)*time.Minute), nil, "note")
+	}
+
+	stop := errors.New("stop")
+	seen := 0
+	err := repo.SearchNotesFunc(context.Background(), models.SearchFilters{}, func(note *models.Note) error {
+		seen++
+		if seen == 2 {
+			return stop
+		}
+
+		return nil
+	})
+
+	assert.EqualValues(t, stop, err)
+	assert.EqualValues(t, 2, seen)
+}
+
+func conformanceDelete(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	note := writeNoteAt(t, repo, time.Now(), []string{"git"}, "delete me")
+
+	assert.Nil(t, repo.DeleteNote(ctx, note.ID))
+	assert.EqualValues(t, ErrNoteNotFound, repo.DeleteNote(ctx, note.ID))
+
+	_, err := repo.LookupNote(ctx, note.ID)
+	assert.EqualValues(t, ErrNoteNotFound, err)
+
+	notes, err := repo.SearchNotes(ctx, models.SearchFilters{Tags: []string{"git"}})
+	assert.Nil(t, err)
+	assert.Empty(t, notes)
+}
+
+func conformanceUpdate(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	note := writeNoteAt(t, repo, time.Now(), []string{"git"}, "before")
+
+	note.Content = "after"
+	assert.Nil(t, repo.UpdateNote(ctx, note))
+
+	found, err := repo.LookupNoteWithTags(ctx, note.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "after", found.Content)
+	assert.EqualValues(t, []string{"git"}, found.Tags)
+
+	assert.EqualValues(t, ErrFailedToUpdateNote, repo.UpdateNote(ctx, models.NewNote(nil, "missing")))
+}
+
+func conformanceTitles(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	titled := models.NewNote(nil, "- did things\n- will do more things")
+	titled.Title = "Standup"
+	assert.Nil(t, repo.WriteNote(ctx, titled))
+	untitled := writeNoteAt(t, repo, time.Now(), nil, "Rebasing notes\ngit rebase -i COMMIT")
+
+	found, err := repo.LookupNote(ctx, titled.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "Standup", found.Title)
+	assert.True(t, found.UpdatedAt.IsZero())
+
+	tests := []struct {
+		title    string
+		expected []string
+	}{
+		{"standup", []string{titled.ID}},
+		{"REBASING", []string{untitled.ID}},
+		{"things", []string{}},
+		{"git rebase", []string{}},
+	}
+
+	for _, test := range tests {
+		notes, err := repo.SearchNotes(ctx, models.SearchFilters{Title: test.title})
+		assert.Nil(t, err, test.title)
+		assert.EqualValues(t, test.expected, noteIds(notes), test.title)
+	}
+
+	titled.Title = "Daily standup"
+	assert.Nil(t, repo.UpdateNote(ctx, titled))
+
+	found, err = repo.LookupNote(ctx, titled.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "Daily standup", found.Title)
+	assert.False(t, found.UpdatedAt.IsZero())
+
+	assert.Nil(t, repo.TagNote(ctx, untitled, []string{"git"}))
+	found, err = repo.LookupNote(ctx, untitled.ID)
+	assert.Nil(t, err)
+	assert.False(t, found.UpdatedAt.IsZero())
+}
+
+func conformanceMeta(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	now := time.Now()
+
+	apollo := models.NewNote(nil, "launch checklist")
+	apollo.Timestamp = now.Add(-2 * time.Hour)
+	apollo.Meta = map[string]string{"project": "apollo", "priority": "2", "ticket": "ABC-12"}
+	assert.Nil(t, repo.WriteNote(ctx, apollo))
+
+	gemini := models.NewNote(nil, "docking procedure")
+	gemini.Timestamp = now.Add(-time.Hour)
+	gemini.Meta = map[string]string{"project": "gemini", "priority": "10"}
+	assert.Nil(t, repo.WriteNote(ctx, gemini))
+
+	plain := writeNoteAt(t, repo, now, nil, "no metadata")
+
+	found, err := repo.LookupNote(ctx, apollo.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, apollo.Meta, found.Meta)
+
+	tests := []struct {
+		expression string
+		expected   []string
+	}{
+		{"project=apollo", []string{apollo.ID}},
+		{"project=APOLLO", []string{apollo.ID}},
+		{"project!=apollo", []string{gemini.ID}},
+		{"ticket", []string{apollo.ID}},
+		{"priority>2", []string{gemini.ID}},
+		{"priority>=2", []string{apollo.ID, gemini.ID}},
+		{"priority<10", []string{apollo.ID}},
+		{"project>1", []string{}},
+		{"missing", []string{}},
+	}
+
+	for _, test := range tests {
+		filter, err := models.ParseMetaFilter(test.expression)
+		assert.Nil(t, err, test.expression)
+
+		notes, err := repo.SearchNotes(ctx, models.SearchFilters{Meta: []models.MetaFilter{filter}})
+		assert.Nil(t, err, test.expression)
+		assert.EqualValues(t, test.expected, noteIds(notes), test.expression)
+
+		for _, note := range notes {
+			assert.NotEmpty(t, note.Meta, test.expression)
+		}
+	}
+
+	notes, err := repo.SearchNotes(ctx, models.SearchFilters{})
+	assert.Nil(t, err)
+	assert.Len(t, notes, 3)
+	assert.Empty(t, notes[2].Meta)
+	assert.EqualValues(t, plain.ID, notes[2].ID)
+
+	apollo.Meta = map[string]string{"project": "apollo"}
+	assert.Nil(t, repo.UpdateNote(ctx, apollo))
+
+	found, err = repo.LookupNoteWithTags(ctx, apollo.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, apollo.Meta, found.Meta)
+
+	assert.Nil(t, repo.DeleteNote(ctx, gemini.ID))
+	filter, _ := models.ParseMetaFilter("project")
+	notes, err = repo.SearchNotes(ctx, models.SearchFilters{Meta: []models.MetaFilter{filter}})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{apollo.ID}, noteIds(notes))
+}
+
+func conformanceTag(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	note := writeNoteAt(t, repo, time.Now(), []string{"git"}, "retag me")
+
+	assert.Nil(t, repo.TagNote(ctx, note, []string{"cli", "shell"}))
+
+	found, err := repo.LookupNoteWithTags(ctx, note.ID)
+	assert.Nil(t, err)
+	sort.Strings(found.Tags)
+	assert.EqualValues(t, []string{"cli", "shell"}, found.Tags)
+
+	notes, err := repo.SearchNotes(ctx, models.SearchFilters{Tags: []string{"git"}})
+	assert.Nil(t, err)
+	assert.Empty(t, notes)
+
+	assert.Nil(t, repo.TagNote(ctx, note, nil))
+	found, err = repo.LookupNoteWithTags(ctx, note.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, found.Tags)
+
+	assert.EqualValues(t, ErrNoteNotFound, repo.TagNote(ctx, models.NewNote(nil, "missing"), []string{"git"}))
+}
+
+func conformanceCancelled(t *testing.T, repo Repository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.EqualValues(t, context.Canceled, repo.WriteNote(ctx, models.NewNote(nil, "never written")))
+
+	notes, err := repo.SearchNotes(context.Background(), models.SearchFilters{})
+	assert.Nil(t, err)
+	assert.Empty(t, notes)
+
+	_, err = repo.SearchNotes(ctx, models.SearchFilters{})
+	assert.EqualValues(t, context.Canceled, err)
+}
+
+func TestSqlRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) (Repository, func()) {
+		return newTestSqlRepository(t)
+	})
+}
+
+func TestMemoryRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) (Repository, func()) {
+		return NewMemoryRepository(), func() {}
+	})
+}
+
+func conformanceTagTree(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	now := time.Now()
+
+	work := writeNoteAt(t, repo, now.Add(-4*time.Hour), []string{"work"}, "work")
+	k8s := writeNoteAt(t, repo, now.Add(-3*time.Hour), []string{"work/infra/k8s"}, "k8s")
+	golang := writeNoteAt(t, repo, now.Add(-2*time.Hour), []string{"lang/go", "work/infra"}, "go")
+	writeNoteAt(t, repo, now.Add(-time.Hour), []string{"workshop", "work_log"}, "workshop")
+
+	tests := []struct {
+		tags     []string
+		tagTree  bool
+		expected []string
+	}{
+		{[]string{"work"}, false, []string{work.ID}},
+		{[]string{"work"}, true, []string{work.ID, k8s.ID, golang.ID}},
+		{[]string{"WORK/infra"}, true, []string{k8s.ID, golang.ID}},
+		{[]string{"work/infra/k8s", "lang"}, true, []string{k8s.ID, golang.ID}},
+		{[]string{"work_"}, true, []string{}},
+	}
+
+	for _, test := range tests {
+		notes, err := repo.SearchNotes(ctx, models.SearchFilters{Tags: test.tags, TagTree: test.tagTree})
+		assert.Nil(t, err, test.tags)
+		assert.EqualValues(t, test.expected, noteIds(notes), test.tags)
+	}
+
+	tags, err := repo.ListTags(ctx)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []models.TagCount{
+		{Tag: "lang/go", Count: 1},
+		{Tag: "work", Count: 1},
+		{Tag: "work/infra", Count: 1},
+		{Tag: "work/infra/k8s", Count: 1},
+		{Tag: "work_log", Count: 1},
+		{Tag: "workshop", Count: 1},
+	}, tags)
+}
+
+func conformanceRenameTag(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	now := time.Now()
+
+	parent := writeNoteAt(t, repo, now.Add(-3*time.Hour), []string{"work", "job"}, "parent")
+	child := writeNoteAt(t, repo, now.Add(-2*time.Hour), []string{"work/infra/k8s", "cli"}, "child")
+	other := writeNoteAt(t, repo, now.Add(-time.Hour), []string{"workshop"}, "other")
+
+	renamed, err := repo.RenameTag(ctx, "work", "job")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, renamed)
+
+	found, err := repo.LookupNoteWithTags(ctx, parent.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"job"}, found.Tags)
+
+	found, err = repo.LookupNoteWithTags(ctx, child.ID)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"job/infra/k8s", "cli"}, found.Tags)
+
+	found, err = repo.LookupNoteWithTags(ctx, other.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"workshop"}, found.Tags)
+
+	renamed, err = repo.RenameTag(ctx, "missing", "found")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, renamed)
+}
+
+func conformanceLinks(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	now := time.Now()
+
+	bug := writeNoteAt(t, repo, now.Add(-4*time.Hour), nil, "the bug")
+	fix := writeNoteAt(t, repo, now.Add(-3*time.Hour), nil, "the fix for [["+bug.ID+"]]")
+
+	twin := models.NewNote(nil, "twin one")
+	twin.ID = "aaaaaaaa-0000-0000-0000-000000000001"
+	twin.Timestamp = now.Add(-2 * time.Hour)
+	assert.Nil(t, repo.WriteNote(ctx, twin))
+	otherTwin := models.NewNote(nil, "twin two")
+	otherTwin.ID = "aaaaaaaa-0000-0000-0000-000000000002"
+	otherTwin.Timestamp = now.Add(-2 * time.Hour)
+	assert.Nil(t, repo.WriteNote(ctx, otherTwin))
+
+	followUp := models.NewNote(nil, "follow up on [["+bug.ID[:8]+"]], [["+fix.ID+"]], [[aaaaaaaa]] and [[ffffffff]]")
+	followUp.Timestamp = now.Add(-time.Hour)
+	assert.Nil(t, repo.WriteNote(ctx, followUp))
+
+	links, err := repo.Links(ctx, followUp.ID)
+	assert.Nil(t, err)
+	assert.Len(t, links, 4)
+	assert.EqualValues(t, bug.ID, links[0].Note.ID)
+	assert.EqualValues(t, fix.ID, links[1].Note.ID)
+	assert.True(t, links[2].Broken())
+	assert.True(t, links[2].Ambiguous)
+	assert.True(t, links[3].Broken())
+	assert.False(t, links[3].Ambiguous)
+
+	backlinks, err := repo.Backlinks(ctx, bug.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{fix.ID, followUp.ID}, noteIds(backlinks))
+
+	// Deleted notes leave broken links behind.
+	assert.Nil(t, repo.DeleteNote(ctx, fix.ID))
+	links, err = repo.Links(ctx, followUp.ID)
+	assert.Nil(t, err)
+	assert.True(t, links[1].Broken())
+
+	backlinks, err = repo.Backlinks(ctx, bug.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{followUp.ID}, noteIds(backlinks))
+
+	// Edits replace a note's links.
+	followUp.Content = "nothing to see"
+	assert.Nil(t, repo.UpdateNote(ctx, followUp))
+	links, err = repo.Links(ctx, followUp.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, links)
+
+	backlinks, err = repo.Backlinks(ctx, bug.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, backlinks)
+
+	_, err = repo.Links(ctx, fix.ID)
+	assert.EqualValues(t, ErrNoteNotFound, err)
+}
+
+func conformanceDue(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	overdue := models.NewNote(nil, "file taxes")
+	overdue.Due = now.Add(-time.Hour)
+	assert.Nil(t, repo.WriteNote(ctx, overdue))
+
+	// Due times written in another time zone still compare correctly.
+	upcoming := models.NewNote(nil, "call the dentist")
+	upcoming.Due = now.Add(2 * time.Hour).In(time.FixedZone("UTC+10", 10*60*60))
+	assert.Nil(t, repo.WriteNote(ctx, upcoming))
+
+	later := models.NewNote(nil, "renew passport")
+	later.Due = now.Add(72 * time.Hour)
+	assert.Nil(t, repo.WriteNote(ctx, later))
+
+	writeNoteAt(t, repo, now, nil, "not due")
+
+	found, err := repo.LookupNote(ctx, overdue.ID)
+	assert.Nil(t, err)
+	assert.True(t, found.Due.Equal(overdue.Due))
+	assert.True(t, found.CompletedAt.IsZero())
+
+	notes, err := repo.SearchNotes(ctx, models.SearchFilters{DueBefore: now, Incomplete: true})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{overdue.ID}, noteIds(notes))
+
+	notes, err = repo.SearchNotes(ctx, models.SearchFilters{DueBefore: now.Add(24 * time.Hour), Incomplete: true})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{overdue.ID, upcoming.ID}, noteIds(notes))
+
+	assert.Nil(t, repo.SetNoteStatus(ctx, overdue.ID, models.StatusDone, now))
+	assert.EqualValues(t, ErrNoteNotFound, repo.SetNoteStatus(ctx, models.NewNote(nil, "missing").ID, models.StatusDone, now))
+
+	found, err = repo.LookupNote(ctx, overdue.ID)
+	assert.Nil(t, err)
+	assert.True(t, found.CompletedAt.Equal(now))
+
+	notes, err = repo.SearchNotes(ctx, models.SearchFilters{DueBefore: now, Incomplete: true})
+	assert.Nil(t, err)
+	assert.Empty(t, notes)
+
+	notes, err = repo.SearchNotes(ctx, models.SearchFilters{DueBefore: now})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{overdue.ID}, noteIds(notes))
+
+	// Updates can move a due date.
+	later.Due = now.Add(-2 * time.Hour)
+	assert.Nil(t, repo.UpdateNote(ctx, later))
+	notes, err = repo.SearchNotes(ctx, models.SearchFilters{DueBefore: now, Incomplete: true})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{later.ID}, noteIds(notes))
+}
+
+func conformancePins(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	now := time.Now()
+
+	first := writeNoteAt(t, repo, now.Add(-2*time.Hour), nil, "first")
+	second := writeNoteAt(t, repo, now.Add(-time.Hour), nil, "git rebase -i")
+	third := writeNoteAt(t, repo, now, nil, "third")
+
+	assert.Nil(t, repo.PinNote(ctx, second.ID, true))
+	assert.EqualValues(t, ErrNoteNotFound, repo.PinNote(ctx, models.NewNote(nil, "missing").ID, true))
+
+	found, err := repo.LookupNote(ctx, second.ID)
+	assert.Nil(t, err)
+	assert.True(t, found.Pinned)
+
+	notes, err := repo.SearchNotes(ctx, models.SearchFilters{})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{second.ID, first.ID, third.ID}, noteIds(notes))
+
+	notes, err = repo.SearchNotes(ctx, models.SearchFilters{Pinned: true})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{second.ID}, noteIds(notes))
+
+	assert.Nil(t, repo.PinNote(ctx, second.ID, false))
+	notes, err = repo.SearchNotes(ctx, models.SearchFilters{})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{first.ID, second.ID, third.ID}, noteIds(notes))
+}
+
+func conformanceFuzzy(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	now := time.Now()
+
+	k8s := writeNoteAt(t, repo, now.Add(-time.Hour), []string{"work"}, "Restarted the Kubernetes deployment")
+	git := writeNoteAt(t, repo, now, nil, "git rebase -i HEAD~3")
+
+	tests := []struct {
+		search   string
+		expected []string
+	}{
+		{"kubernets", []string{k8s.ID}},
+		{"rebse", []string{git.ID}},
+		{"KUBE deploymnt", []string{k8s.ID}},
+		{"kubernets rebse", []string{}},
+		{"coltrane", []string{}},
+
+		// Short words match the words containing them, and searches split into words like content does.
+		{"ub", []string{k8s.ID}},
+		{"head~3", []string{git.ID}},
+	}
+
+	for _, test := range tests {
+		notes, err := repo.SearchNotes(ctx, models.SearchFilters{Fuzzy: test.search})
+		assert.Nil(t, err, test.search)
+		assert.EqualValues(t, test.expected, noteIds(notes), test.search)
+	}
+
+	// Fuzzy search combines with other filters, and follows content as it's updated.
+	notes, err := repo.SearchNotes(ctx, models.SearchFilters{Fuzzy: "rebse", Tags: []string{"work"}})
+	assert.Nil(t, err)
+	assert.Empty(t, notes)
+
+	git.Content = "git bisect"
+	assert.Nil(t, repo.UpdateNote(ctx, git))
+	notes, err = repo.SearchNotes(ctx, models.SearchFilters{Fuzzy: "bisct"})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{git.ID}, noteIds(notes))
+
+	assert.Nil(t, repo.DeleteNote(ctx, git.ID))
+	notes, err = repo.SearchNotes(ctx, models.SearchFilters{Fuzzy: "bisct"})
+	assert.Nil(t, err)
+	assert.Empty(t, notes)
+}
+
+func conformanceRegex(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	now := time.Now()
+
+	todo := writeNoteAt(t, repo, now.Add(-48*time.Hour), []string{"work"}, "TODO(alice): rotate keys on 10.0.0.12")
+	ticket := writeNoteAt(t, repo, now, []string{"work"}, "Fixed SF-142, see TODO(bob)")
+	writeNoteAt(t, repo, now, nil, "todo list")
+
+	today := models.GetRangeToday(now)
+
+	tests := []struct {
+		name     string
+		filters  models.SearchFilters
+		expected []string
+	}{
+		{"pattern", models.SearchFilters{Regex: `TODO\(\w+\)`}, []string{todo.ID, ticket.ID}},
+		{"case sensitive", models.SearchFilters{Regex: `^todo\(`}, []string{}},
+		{"ignoring case", models.SearchFilters{Regex: `(?i)^todo\(`}, []string{todo.ID}},
+		{"address", models.SearchFilters{Regex: `\d+\.\d+\.\d+\.\d+`}, []string{todo.ID}},
+		{"with tags and dates", models.SearchFilters{Regex: `TODO\(`, Tags: []string{"work"}, DateRange: &today}, []string{ticket.ID}},
+	}
+
+	for _, test := range tests {
+		notes, err := repo.SearchNotes(ctx, test.filters)
+		assert.Nil(t, err, test.name)
+		assert.EqualValues(t, test.expected, noteIds(notes), test.name)
+	}
+}
+
+func conformanceStatus(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	open := models.NewNote(nil, "buy milk")
+	open.Status = models.StatusOpen
+	assert.Nil(t, repo.WriteNote(ctx, open))
+
+	done := models.NewNote(nil, "book flights")
+	done.Status = models.StatusOpen
+	assert.Nil(t, repo.WriteNote(ctx, done))
+
+	plain := writeNoteAt(t, repo, now, nil, "not a task")
+
+	found, err := repo.LookupNote(ctx, open.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, models.StatusOpen, found.Status)
+
+	assert.Nil(t, repo.SetNoteStatus(ctx, done.ID, models.StatusDone, now))
+	assert.EqualValues(t, ErrNoteNotFound, repo.SetNoteStatus(ctx, models.NewNote(nil, "missing").ID, models.StatusDone, now))
+
+	found, err = repo.LookupNote(ctx, done.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, models.StatusDone, found.Status)
+	assert.True(t, found.CompletedAt.Equal(now))
+
+	notes, err := repo.SearchNotes(ctx, models.SearchFilters{Status: []models.Status{models.StatusOpen}})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{open.ID}, noteIds(notes))
+
+	notes, err = repo.SearchNotes(ctx, models.SearchFilters{Status: models.Statuses})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{open.ID, done.ID}, noteIds(notes))
+
+	notes, err = repo.SearchNotes(ctx, models.SearchFilters{Status: []models.Status{models.StatusNone}})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{plain.ID}, noteIds(notes))
+
+	// Reopening a task clears when it was completed.
+	assert.Nil(t, repo.SetNoteStatus(ctx, done.ID, models.StatusOpen, now))
+	found, err = repo.LookupNote(ctx, done.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, models.StatusOpen, found.Status)
+	assert.True(t, found.CompletedAt.IsZero())
+
+	assert.Nil(t, repo.SetNoteStatus(ctx, open.ID, models.StatusCancelled, now))
+	notes, err = repo.SearchNotes(ctx, models.SearchFilters{Status: []models.Status{models.StatusOpen}, Incomplete: true})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{done.ID}, noteIds(notes))
+}
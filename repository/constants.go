@@ -1,10 +1,25 @@
 package repository
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	ErrNoteNotFound       = errors.New("note not found")
 	ErrFailedToUpdateNote = errors.New("failed to update note")
+	ErrNoteExists         = errors.New("note already exists")
+
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrAttachmentExists   = errors.New("note already has an attachment with that name")
+)
+
+const (
+	// How many times a transaction is attempted while the database is busy.
+	transactionAttempts = 5
+
+	// Base delay between transaction attempts, grows with each attempt.
+	transactionRetryDelay = 50 * time.Millisecond
 )
 
 const sqlInitializeDatabase = `
@@ -31,36 +46,235 @@ CREATE INDEX IF NOT EXISTS note_tags_note_id_index ON note_tags (note_id);
 CREATE INDEX IF NOT EXISTS note_tags_tag_index ON note_tags (tag);
 `
 
+// Schema changes made since sqlInitializeDatabase, applied in order.
+// A database's user_version is the number of migrations applied to it.
+var sqlMigrations = []string{
+	// 1: Note titles, and when a note was last updated.
+	`
+	ALTER TABLE notes ADD COLUMN title TEXT NOT NULL DEFAULT '';
+	ALTER TABLE notes ADD COLUMN updated_at TIMESTAMP;
+	`,
+
+	// 2: Key/value metadata. Values which are numbers are also kept in number, for comparisons.
+	`
+	CREATE TABLE IF NOT EXISTS note_meta (note_id CHAR(16) NOT NULL,
+	                                      key VARCHAR(50) NOT NULL,
+	                                      value TEXT NOT NULL,
+	                                      number REAL);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS note_meta_note_id_key_uindex ON note_meta (note_id, key);
+
+	CREATE INDEX IF NOT EXISTS note_meta_key_index ON note_meta (key);
+	`,
+
+	// 3: Normalize existing tags, see normalizeTags.
+	`
+	CREATE INDEX IF NOT EXISTS note_tags_note_id_tag_index ON note_tags (note_id, tag);
+	`,
+
+	// 4: Links between notes, filled from existing content by indexLinks.
+	`
+	CREATE TABLE IF NOT EXISTS note_links (note_id CHAR(16) NOT NULL,
+	                                       target VARCHAR(36) NOT NULL);
+
+	CREATE INDEX IF NOT EXISTS note_links_note_id_index ON note_links (note_id);
+
+	CREATE INDEX IF NOT EXISTS note_links_target_index ON note_links (target);
+	`,
+
+	// 5: Files stored alongside notes.
+	`
+	CREATE TABLE IF NOT EXISTS attachments (note_id CHAR(16) NOT NULL,
+	                                        name TEXT NOT NULL,
+	                                        size INTEGER NOT NULL,
+	                                        hash CHAR(64) NOT NULL,
+	                                        data BLOB NOT NULL,
+	                                        timestamp TIMESTAMP NOT NULL);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS attachments_note_id_name_uindex ON attachments (note_id, name);
+	`,
+
+	// 6: Due dates, and when notes were completed.
+	`
+	ALTER TABLE notes ADD COLUMN due TIMESTAMP;
+	ALTER TABLE notes ADD COLUMN completed_at TIMESTAMP;
+
+	CREATE INDEX IF NOT EXISTS notes_due_index ON notes (due);
+	`,
+
+	// 7: Task status, notes already completed are done tasks.
+	`
+	ALTER TABLE notes ADD COLUMN status TEXT NOT NULL DEFAULT '';
+
+	UPDATE notes SET status = 'done' WHERE completed_at IS NOT NULL;
+
+	CREATE INDEX IF NOT EXISTS notes_status_index ON notes (status);
+	`,
+
+	// 8: Pinned notes.
+	`
+	ALTER TABLE notes ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT 0;
+	`,
+
+	// 9: Trigrams of note content for fuzzy search, filled from existing content by indexTrigrams.
+	`
+	CREATE TABLE IF NOT EXISTS note_trigrams (note_id CHAR(16) NOT NULL,
+	                                          trigram CHAR(3) NOT NULL);
+
+	CREATE INDEX IF NOT EXISTS note_trigrams_note_id_index ON note_trigrams (note_id);
+
+	CREATE INDEX IF NOT EXISTS note_trigrams_trigram_index ON note_trigrams (trigram);
+	`,
+}
+
+const sqlListDistinctTags = `SELECT DISTINCT tag FROM note_tags`
+
+const sqlReplaceTag = `UPDATE note_tags SET tag = ? WHERE tag = ?`
+
+const sqlDeleteEmptyTags = `DELETE FROM note_tags WHERE tag = ''`
+
+const sqlGetSchemaVersion = `PRAGMA user_version`
+
+const sqlSetSchemaVersion = `PRAGMA user_version = %d`
+
+// Columns read into a note by scanNote, in order.
+// Metadata is aggregated into a single column, see splitMeta.
+const sqlNoteColumns = `notes.id, notes.timestamp, notes.content, notes.title, notes.updated_at,
+	notes.due, notes.status, notes.completed_at, notes.pinned,
+	COALESCE((
+		SELECT GROUP_CONCAT(note_meta.key || char(30) || note_meta.value, char(31))
+		FROM note_meta
+		WHERE note_meta.note_id = notes.id
+	), '') as meta`
+
 const sqlInsertNote = `
-INSERT INTO notes (id, timestamp, content)
-VALUES (?, ?, ?)
+INSERT INTO notes (id, timestamp, content, title, due, status, completed_at, pinned)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 const sqlInsertNoteTags = `INSERT INTO note_tags (note_id, tag) VALUES`
 
+const sqlInsertNoteMeta = `INSERT INTO note_meta (note_id, key, value, number) VALUES (?, ?, ?, ?)`
+
+const sqlDeleteNoteMeta = `DELETE FROM note_meta WHERE note_meta.note_id = ?`
+
+const sqlFilterNotesByMeta = `notes.id IN (SELECT note_id FROM note_meta WHERE key = ?%s)`
+
+// Tags are aggregated from every tag row of a note. Filters must not restrict the
+// note_tags join itself, or notes would come back with only the matching tags.
 const sqlSearchNotes = `
-SELECT notes.id, notes.content, COALESCE(GROUP_CONCAT(DISTINCT note_tags.tag), "") as tags, notes.timestamp
+SELECT ` + sqlNoteColumns + `, COALESCE(GROUP_CONCAT(DISTINCT note_tags.tag), '') as tags
 FROM notes
 LEFT JOIN note_tags
     ON note_tags.note_id = notes.id
 
 -- WHERE clause
 %s
-COLLATE NOCASE
 GROUP BY notes.id
-ORDER BY notes.timestamp
+ORDER BY notes.pinned DESC, notes.timestamp
 `
 
+const sqlFilterNotesByTags = `notes.id IN (SELECT note_id FROM note_tags WHERE tag COLLATE NOCASE IN (%s))`
+
+// A note's title, falling back to the first line of its content like Note.GetTitle.
+const sqlFilterNotesByTitle = `
+(CASE WHEN notes.title != '' THEN notes.title
+ELSE substr(notes.content, 1, instr(notes.content || char(10), char(10)) - 1) END) LIKE ?`
+
 const sqlUpdateNote = `UPDATE notes SET content=? WHERE id=?`
 
 const sqlDeleteNote = "DELETE FROM notes WHERE notes.id = ?"
 
+// Matches a tag, or any tag beneath it. Arguments are the tag, then the tag as a LIKE prefix.
+const sqlTagWithin = `(tag = ? COLLATE NOCASE OR tag LIKE ? ESCAPE '\')`
+
+const sqlFilterNotesByTagTree = `notes.id IN (SELECT note_id FROM note_tags WHERE %s)`
+
+const sqlListTags = `
+SELECT tag, COUNT(DISTINCT note_id)
+FROM note_tags
+WHERE note_id IN (SELECT id FROM notes)
+GROUP BY tag
+ORDER BY tag
+`
+
+const sqlCountNotesWithinTag = `SELECT COUNT(DISTINCT note_id) FROM note_tags WHERE ` + sqlTagWithin
+
+// Replaces the renamed part of each tag, keeping the levels beneath it.
+const sqlRenameTags = `UPDATE note_tags SET tag = ? || substr(tag, ?) WHERE ` + sqlTagWithin
+
+// Renaming can give a note the same tag twice.
+const sqlDeleteDuplicateTags = `
+DELETE FROM note_tags
+WHERE rowid NOT IN (SELECT MIN(rowid) FROM note_tags GROUP BY note_id, tag)
+`
+
 const sqlDeleteNoteTags = "DELETE FROM note_tags WHERE note_tags.note_id = ?"
 
-const sqlGetNoteTags = `SELECT GROUP_CONCAT(DISTINCT tag) as tags FROM note_tags WHERE note_id = ? LIMIT 1`
+const sqlGetNoteTags = `SELECT COALESCE(GROUP_CONCAT(DISTINCT tag), '') as tags FROM note_tags WHERE note_id = ?`
+
+const sqlInsertNoteLink = `INSERT INTO note_links (note_id, target) VALUES (?, ?)`
+
+const sqlDeleteNoteLinks = `DELETE FROM note_links WHERE note_links.note_id = ?`
+
+const sqlGetNoteLinks = `SELECT target FROM note_links WHERE note_id = ? ORDER BY rowid`
+
+// Notes a link target could lead to. An exact match comes first, and two rows
+// are enough to tell an ambiguous prefix apart.
+const sqlResolveLink = `
+SELECT ` + sqlNoteColumns + `
+FROM notes
+WHERE notes.id LIKE ? || '%'
+ORDER BY notes.id = ? DESC
+LIMIT 2
+`
+
+// Notes with a link leading to a note ID.
+const sqlFilterNotesLinkingTo = `notes.id IN (SELECT note_id FROM note_links WHERE lower(?) LIKE target || '%')`
+
+const sqlLatestNotes = `
+SELECT ` + sqlNoteColumns + `
+FROM notes
+ORDER BY notes.timestamp DESC, notes.id
+LIMIT ?
+`
+
+const sqlListNoteContent = `SELECT id, content FROM notes`
+
+const sqlInsertNoteTrigram = `INSERT INTO note_trigrams (note_id, trigram) VALUES (?, ?)`
+
+const sqlDeleteNoteTrigrams = `DELETE FROM note_trigrams WHERE note_trigrams.note_id = ?`
+
+// Uses the regexp function registered with the database driver.
+const sqlFilterNotesByRegex = `notes.content REGEXP ?`
+
+// Notes sharing any trigram with a searched word, which fuzzy matches are then found among.
+const sqlFilterNotesByTrigrams = `notes.id IN (SELECT note_id FROM note_trigrams WHERE trigram IN (%s))`
+
+const sqlInsertAttachment = `
+INSERT INTO attachments (note_id, name, size, hash, data, timestamp)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+const sqlListAttachments = `
+SELECT note_id, name, size, hash, timestamp
+FROM attachments
+WHERE note_id = ?
+ORDER BY name
+`
+
+const sqlGetAttachment = `
+SELECT note_id, name, size, hash, timestamp, data
+FROM attachments
+WHERE note_id = ? AND name = ?
+`
+
+const sqlDeleteNoteAttachments = `DELETE FROM attachments WHERE attachments.note_id = ?`
+
+const sqlNoteExists = `SELECT 1 FROM notes WHERE id = ?`
 
 const sqlGetNote = `
-SELECT notes.id, timestamp, content
+SELECT ` + sqlNoteColumns + `
 FROM notes
 WHERE notes.id = ?
 `
@@ -68,6 +282,89 @@ WHERE notes.id = ?
 const sqlUpdateNoteContent = `
 UPDATE notes
 SET
-	content = ?
+	content = ?,
+	title = ?,
+	due = ?,
+	updated_at = ?
 WHERE id = ?
 `
+
+const sqlSetNoteStatus = `UPDATE notes SET status = ?, completed_at = ?, updated_at = ? WHERE id = ?`
+
+// Compared as UTC, since times are stored with the offset they were written in.
+const sqlFilterNotesByDue = `notes.due IS NOT NULL AND datetime(notes.due) <= datetime(?)`
+
+const sqlFilterNotesIncomplete = `notes.completed_at IS NULL`
+
+const sqlFilterNotesByStatus = `notes.status IN (%s)`
+
+const sqlFilterNotesPinned = `notes.pinned`
+
+const sqlPinNote = `UPDATE notes SET pinned = ? WHERE id = ?`
+
+const sqlTouchNote = `UPDATE notes SET updated_at = ? WHERE id = ?`
+
+const sqlIntegrityCheck = `PRAGMA integrity_check`
+
+const sqlFindOrphanTags = `
+SELECT note_tags.note_id, note_tags.tag
+FROM note_tags
+LEFT JOIN notes
+    ON notes.id = note_tags.note_id
+WHERE notes.id IS NULL
+`
+
+const sqlDeleteOrphanTags = `DELETE FROM note_tags WHERE note_id NOT IN (SELECT id FROM notes)`
+
+const sqlVacuum = `VACUUM`
+
+const sqlOptimize = `PRAGMA optimize`
+
+const sqlCountNotes = `SELECT COUNT(*) FROM notes`
+
+const sqlCountTags = `SELECT COUNT(DISTINCT tag) FROM note_tags`
+
+const sqlOldestNoteTimestamp = `SELECT timestamp FROM notes ORDER BY timestamp ASC LIMIT 1`
+
+const sqlNewestNoteTimestamp = `SELECT timestamp FROM notes ORDER BY timestamp DESC LIMIT 1`
+
+const sqlLargestNotes = `
+SELECT ` + sqlNoteColumns + `
+FROM notes
+ORDER BY LENGTH(notes.content) DESC
+LIMIT ?
+`
+
+// Notes written between two times, compared as UTC.
+const sqlFilterNotesWrittenBetween = `datetime(notes.timestamp) BETWEEN datetime(?) AND datetime(?)`
+
+// How many notes were written between two times, and their words. word_count is registered by the database driver.
+const sqlReportTotals = `
+SELECT COUNT(*), COALESCE(SUM(word_count(notes.content)), 0)
+FROM notes
+WHERE ` + sqlFilterNotesWrittenBetween + `
+`
+
+const sqlReportDays = `
+SELECT date(notes.timestamp, 'localtime') AS day, COUNT(*)
+FROM notes
+WHERE ` + sqlFilterNotesWrittenBetween + `
+GROUP BY day
+ORDER BY day
+`
+
+const sqlReportHours = `
+SELECT CAST(strftime('%H', notes.timestamp, 'localtime') AS INTEGER) AS hour, COUNT(*)
+FROM notes
+WHERE ` + sqlFilterNotesWrittenBetween + `
+GROUP BY hour
+`
+
+const sqlReportTags = `
+SELECT note_tags.tag, COUNT(DISTINCT notes.id) AS count
+FROM notes
+JOIN note_tags ON note_tags.note_id = notes.id
+WHERE ` + sqlFilterNotesWrittenBetween + `
+GROUP BY note_tags.tag
+ORDER BY count DESC, note_tags.tag
+`
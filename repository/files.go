@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"encoding/json"
+	"github.com/ricanontherun/short-form/models"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Name of the index file kept alongside the notes.
+const filesIndexName = ".index.json"
+
+// An index entry caches a parsed note file, valid whilst the file is unchanged.
+type filesIndexEntry struct {
+	File    string       `json:"file"`
+	ModTime time.Time    `json:"mod_time"`
+	Size    int64        `json:"size"`
+	Note    *models.Note `json:"note"`
+}
+
+// filesStore keeps one Markdown file per note in a directory, so notes can be
+// read and grepped without sf. The index means only files which changed since
+// the last run have to be parsed again.
+type filesStore struct {
+	dir   string
+	index map[string]filesIndexEntry
+}
+
+// NewFilesRepository opens a repository storing notes as Markdown files in dir.
+// Notes are loaded into memory, and searched there.
+func NewFilesRepository(dir string) (Repository, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	store := &filesStore{dir: dir, index: make(map[string]filesIndexEntry)}
+	repository := newMemoryRepository(store)
+
+	if err := store.load(repository.notes); err != nil {
+		return nil, err
+	}
+
+	return repository, nil
+}
+
+// Load every note file into notes, using the index for files which haven't changed.
+func (store *filesStore) load(notes map[string]*models.Note) error {
+	cached := make(map[string]filesIndexEntry)
+	if indexBytes, err := ioutil.ReadFile(store.indexPath()); err == nil {
+		var index map[string]filesIndexEntry
+		if err := json.Unmarshal(indexBytes, &index); err == nil {
+			for _, entry := range index {
+				cached[entry.File] = entry
+			}
+		}
+	}
+
+	files, err := ioutil.ReadDir(store.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".md") {
+			continue
+		}
+
+		entry, ok := cached[file.Name()]
+		if !ok || entry.Note == nil || !entry.ModTime.Equal(file.ModTime()) || entry.Size != file.Size() {
+			data, err := ioutil.ReadFile(filepath.Join(store.dir, file.Name()))
+			if err != nil {
+				return err
+			}
+
+			note, err := parseNoteFile(data)
+			if err != nil {
+				log.Printf("skipping note file %s: %s\n", file.Name(), err.Error())
+				continue
+			}
+
+			entry = filesIndexEntry{File: file.Name(), ModTime: file.ModTime(), Size: file.Size(), Note: note}
+		}
+
+		if _, duplicate := notes[entry.Note.ID]; duplicate {
+			log.Printf("skipping note file %s: duplicate note id %s\n", file.Name(), entry.Note.ID)
+			continue
+		}
+
+		notes[entry.Note.ID] = entry.Note
+		store.index[entry.Note.ID] = entry
+	}
+
+	return store.writeIndex()
+}
+
+func (store *filesStore) saveNote(note *models.Note) error {
+	fileName := noteFileName(note)
+	if entry, exists := store.index[note.ID]; exists {
+		fileName = entry.File
+	}
+
+	path := filepath.Join(store.dir, fileName)
+	if err := writeFileAtomic(path, formatNoteFile(note)); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	store.index[note.ID] = filesIndexEntry{File: fileName, ModTime: info.ModTime(), Size: info.Size(), Note: note}
+
+	return store.writeIndex()
+}
+
+func (store *filesStore) removeNote(noteId string) error {
+	entry, exists := store.index[noteId]
+	if !exists {
+		return nil
+	}
+
+	if err := os.Remove(filepath.Join(store.dir, entry.File)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	delete(store.index, noteId)
+
+	return store.writeIndex()
+}
+
+func (store *filesStore) indexPath() string {
+	return filepath.Join(store.dir, filesIndexName)
+}
+
+func (store *filesStore) writeIndex() error {
+	indexBytes, err := json.Marshal(store.index)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(store.indexPath(), indexBytes)
+}
+
+// Write a file via a temporary file, so readers never see it half written.
+func writeFileAtomic(path string, data []byte) error {
+	temp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+
+	return os.Rename(temp.Name(), path)
+}
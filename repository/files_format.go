@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/ricanontherun/short-form/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Note files are Markdown, with the note's fields in a front matter block, e.g
+//
+//	---
+//	id: 0b5d3e4e-9c6a-4b8e-8f5e-5b7a1d2c3e4f
+//	timestamp: 2019-12-08T14:39:00Z
+//	title: Rebasing
+//	tags: git, cli
+//	meta.project: apollo
+//	---
+//
+//	git rebase: git rebase COMMIT
+const frontMatterDelimiter = "---"
+
+// Metadata keys are written as meta.KEY: VALUE
+const metaKeyPrefix = "meta."
+
+// Keys and values which can't be written as they are, such as a title spanning lines or
+// a metadata key containing a colon, are written as Go quoted strings, e.g meta."a:b": "x\ny".
+const frontMatterQuote = '"'
+
+var errMissingFrontMatter = errors.New("missing front matter")
+
+// File name for a note, sortable by date.
+func noteFileName(note *models.Note) string {
+	return note.Timestamp.Format("2006-01-02-150405") + "-" + note.ID + ".md"
+}
+
+func formatNoteFile(note *models.Note) []byte {
+	var buffer bytes.Buffer
+
+	buffer.WriteString(frontMatterDelimiter + "\n")
+	buffer.WriteString("id: " + note.ID + "\n")
+	buffer.WriteString("timestamp: " + note.Timestamp.Format(time.RFC3339Nano) + "\n")
+
+	if !note.UpdatedAt.IsZero() {
+		buffer.WriteString("updated_at: " + note.UpdatedAt.Format(time.RFC3339Nano) + "\n")
+	}
+
+	if !note.Due.IsZero() {
+		buffer.WriteString("due: " + note.Due.Format(time.RFC3339Nano) + "\n")
+	}
+
+	if len(note.Status) > 0 {
+		buffer.WriteString("status: " + string(note.Status) + "\n")
+	}
+
+	if !note.CompletedAt.IsZero() {
+		buffer.WriteString("completed_at: " + note.CompletedAt.Format(time.RFC3339Nano) + "\n")
+	}
+
+	if note.Pinned {
+		buffer.WriteString("pinned: true\n")
+	}
+
+	if len(note.Title) > 0 {
+		buffer.WriteString("title: " + quoteFrontMatter(note.Title, false) + "\n")
+	}
+
+	if len(note.Tags) > 0 {
+		buffer.WriteString("tags: " + strings.Join(note.Tags, ", ") + "\n")
+	}
+
+	for _, pair := range models.FormatMeta(note.Meta) {
+		separator := strings.Index(pair, "=")
+		key, value := quoteFrontMatter(pair[:separator], true), quoteFrontMatter(pair[separator+1:], false)
+		buffer.WriteString(metaKeyPrefix + key + ": " + value + "\n")
+	}
+
+	buffer.WriteString(frontMatterDelimiter + "\n\n")
+	buffer.WriteString(note.Content + "\n")
+
+	return buffer.Bytes()
+}
+
+func parseNoteFile(data []byte) (*models.Note, error) {
+	lines := strings.Split(strings.Replace(string(data), "\r\n", "\n", -1), "\n")
+
+	if strings.TrimSpace(lines[0]) != frontMatterDelimiter {
+		return nil, errMissingFrontMatter
+	}
+
+	var note models.Note
+	end := -1
+
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == frontMatterDelimiter {
+			end = i
+			break
+		}
+
+		key, value, ok := splitFrontMatterLine(lines[i])
+		if !ok {
+			continue
+		}
+
+		if strings.HasPrefix(key, metaKeyPrefix) {
+			if note.Meta == nil {
+				note.Meta = make(map[string]string)
+			}
+
+			note.Meta[models.NormalizeMetaKey(unquoteFrontMatter(strings.TrimPrefix(key, metaKeyPrefix)))] = unquoteFrontMatter(value)
+			continue
+		}
+
+		switch key {
+		case "id":
+			note.ID = value
+		case "timestamp":
+			timestamp, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp: %s", err.Error())
+			}
+			note.Timestamp = timestamp
+		case "updated_at":
+			updatedAt, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid updated_at: %s", err.Error())
+			}
+			note.UpdatedAt = updatedAt
+		case "due":
+			due, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid due: %s", err.Error())
+			}
+			note.Due = due
+		case "completed_at":
+			completedAt, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid completed_at: %s", err.Error())
+			}
+			note.CompletedAt = completedAt
+		case "status":
+			status, err := models.ParseStatus(value)
+			if err != nil {
+				return nil, err
+			}
+			note.Status = status
+		case "pinned":
+			pinned, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pinned: %s", err.Error())
+			}
+			note.Pinned = pinned
+		case "title":
+			note.Title = unquoteFrontMatter(value)
+		case "tags":
+			// Tags written by hand may not follow the tag policy.
+			for _, tag := range strings.Split(value, ",") {
+				if tag = models.NormalizeTag(tag); len(tag) > 0 {
+					note.Tags = append(note.Tags, tag)
+				}
+			}
+			note.Tags = utils.SliceUniqueStrings(note.Tags)
+		}
+	}
+
+	if end == -1 {
+		return nil, errMissingFrontMatter
+	}
+
+	if len(note.ID) == 0 {
+		return nil, errors.New("missing id")
+	}
+
+	// A blank line separates the front matter from the content, and files end with a newline.
+	content := strings.TrimPrefix(strings.Join(lines[end+1:], "\n"), "\n")
+	note.Content = strings.TrimSuffix(content, "\n")
+
+	return &note, nil
+}
+
+// Quote a front matter key or value if it can't be read back as it's written.
+// Keys also can't contain the colon which ends them.
+func quoteFrontMatter(text string, isKey bool) string {
+	needsQuotes := text != strings.TrimSpace(text) ||
+		strings.ContainsAny(text, "\r\n") ||
+		strings.HasPrefix(text, string(frontMatterQuote)) ||
+		(isKey && strings.Contains(text, ":"))
+
+	if needsQuotes {
+		return strconv.Quote(text)
+	}
+
+	return text
+}
+
+// Read a quoted front matter key or value. Text which isn't quoted, or was quoted by
+// hand and isn't a valid Go string, is read as it is.
+func unquoteFrontMatter(text string) string {
+	if len(text) < 2 || text[0] != frontMatterQuote || text[len(text)-1] != frontMatterQuote {
+		return text
+	}
+
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		return unquoted
+	}
+
+	return text
+}
+
+// Split a front matter line at the colon ending its key, skipping any colons in a quoted key.
+func splitFrontMatterLine(line string) (string, string, bool) {
+	start := 0
+	if quote := strings.Index(line, string(frontMatterQuote)); quote != -1 && quote < strings.Index(line, ":") {
+		// Find the closing quote, stepping over escaped characters.
+		for i := quote + 1; i < len(line); i++ {
+			if line[i] == '\\' {
+				i++
+			} else if line[i] == frontMatterQuote {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	separator := strings.Index(line[start:], ":")
+	if separator == -1 {
+		return "", "", false
+	}
+
+	separator += start
+	return strings.TrimSpace(line[:separator]), strings.TrimSpace(line[separator+1:]), true
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestFilesDirectory(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "short-form-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func TestFilesRepository_Conformance(t *testing.T) {
+	testRepositoryConformance(t, func(t *testing.T) (Repository, func()) {
+		dir, cleanup := newTestFilesDirectory(t)
+
+		repo, err := NewFilesRepository(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return repo, cleanup
+	})
+}
+
+func TestFilesRepository_NotesArePlainMarkdown(t *testing.T) {
+	dir, cleanup := newTestFilesDirectory(t)
+	defer cleanup()
+
+	repo, err := NewFilesRepository(dir)
+	assert.Nil(t, err)
+
+	note := models.NewNote([]string{"git", "cli"}, "git rebase: git rebase COMMIT\n\nsecond paragraph")
+	assert.Nil(t, repo.WriteNote(context.Background(), note))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, noteFileName(&note)))
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "---\nid: "+note.ID+"\n"))
+	assert.True(t, strings.HasSuffix(string(data), "---\n\n"+note.Content+"\n"))
+}
+
+func TestFilesRepository_Reopen(t *testing.T) {
+	dir, cleanup := newTestFilesDirectory(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	repo, err := NewFilesRepository(dir)
+	assert.Nil(t, err)
+
+	kept := models.NewNote([]string{"git"}, "kept")
+	edited := models.NewNote(nil, "edited outside of sf")
+	deleted := models.NewNote(nil, "deleted")
+	for _, note := range []models.Note{kept, edited, deleted} {
+		assert.Nil(t, repo.WriteNote(ctx, note))
+	}
+	assert.Nil(t, repo.DeleteNote(ctx, deleted.ID))
+
+	// Edit a note file by hand, making sure its modification time changes.
+	editedPath := filepath.Join(dir, noteFileName(&edited))
+	data, err := ioutil.ReadFile(editedPath)
+	assert.Nil(t, err)
+	data = []byte(strings.Replace(string(data), "edited outside of sf", "changed by hand", 1))
+	assert.Nil(t, ioutil.WriteFile(editedPath, data, 0644))
+	later := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(editedPath, later, later))
+
+	// A file which isn't a note is ignored.
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("# my notes\n"), 0644))
+
+	reopened, err := NewFilesRepository(dir)
+	assert.Nil(t, err)
+
+	notes, err := reopened.SearchNotes(ctx, models.SearchFilters{})
+	assert.Nil(t, err)
+	assert.Len(t, notes, 2)
+
+	found, err := reopened.LookupNoteWithTags(ctx, kept.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"git"}, found.Tags)
+	assert.True(t, kept.Timestamp.Equal(found.Timestamp))
+
+	found, err = reopened.LookupNote(ctx, edited.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "changed by hand", found.Content)
+
+	_, err = reopened.LookupNote(ctx, deleted.ID)
+	assert.EqualValues(t, ErrNoteNotFound, err)
+}
+
+func TestFilesRepository_QuotesFrontMatter(t *testing.T) {
+	dir, cleanup := newTestFilesDirectory(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	repo, err := NewFilesRepository(dir)
+	assert.Nil(t, err)
+
+	note := models.NewNote(nil, "content")
+	note.Title = "---"
+	note.Meta = map[string]string{
+		"a:b":       "v",
+		"multiline": "first\n---\nid: other",
+		"quoted":    `"as written"`,
+		"url":       "https://example.com",
+	}
+	assert.Nil(t, repo.WriteNote(ctx, note))
+
+	// Read the file itself, rather than the index.
+	data, err := ioutil.ReadFile(filepath.Join(dir, noteFileName(&note)))
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "\nmeta.url: https://example.com\n")
+
+	found, err := parseNoteFile(data)
+	assert.Nil(t, err)
+	assert.EqualValues(t, note.ID, found.ID)
+	assert.EqualValues(t, note.Title, found.Title)
+	assert.EqualValues(t, note.Meta, found.Meta)
+	assert.EqualValues(t, note.Content, found.Content)
+}
+
+func TestParseNoteFile(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected *models.Note
+	}{
+		{
+			input: "---\nid: abc\ntimestamp: 2019-12-08T14:39:00Z\ntags: git,  cli\n---\n\nline one\nline two\n",
+			expected: &models.Note{
+				ID:        "abc",
+				Tags:      []string{"git", "cli"},
+				Content:   "line one\nline two",
+				Timestamp: time.Date(2019, 12, 8, 14, 39, 0, 0, time.UTC),
+			},
+		},
+		{
+			input: "---\r\nid: abc\r\ntimestamp: 2019-12-08T14:39:00Z\r\n---\r\n\r\ncontent\r\n",
+			expected: &models.Note{
+				ID:        "abc",
+				Content:   "content",
+				Timestamp: time.Date(2019, 12, 8, 14, 39, 0, 0, time.UTC),
+			},
+		},
+		{input: "no front matter", expected: nil},
+		{input: "---\nid: abc\nnever closed", expected: nil},
+		{input: "---\ntags: git\n---\nno id", expected: nil},
+	}
+
+	for _, test := range tests {
+		note, err := parseNoteFile([]byte(test.input))
+
+		if test.expected == nil {
+			assert.NotNil(t, err, test.input)
+		} else {
+			assert.Nil(t, err, test.input)
+			assert.EqualValues(t, test.expected, note, test.input)
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/mattn/go-sqlite3"
 	"github.com/ricanontherun/short-form/database"
 	"github.com/ricanontherun/short-form/models"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 type sqlRepository struct {
@@ -27,89 +31,340 @@ func NewSqlRepository(db database.Database) (Repository, error) {
 	return repository, nil
 }
 
-func buildSearchQueryFromContext(ctx models.SearchFilters) string {
+// Build the search query and its arguments for a set of filters.
+func buildSearchQueryFromContext(ctx models.SearchFilters) (string, []interface{}) {
 	var where []string
+	var args []interface{}
 
 	if ctx.DateRange != nil {
-		filter := fmt.Sprintf(
-			" timestamp BETWEEN datetime('%s') and datetime('%s') ",
+		where = append(where, "notes.timestamp BETWEEN datetime(?) AND datetime(?)")
+		args = append(args,
 			ctx.DateRange.From.Format("2006-01-02 15:04:05"),
 			ctx.DateRange.To.Format("2006-01-02 15:04:05"),
 		)
-
-		where = append(where, filter)
 	}
 
-	if len(ctx.Tags) > 0 {
-		quotedTags := make([]string, 0, len(ctx.Tags))
+	if len(ctx.Tags) > 0 && ctx.TagTree {
+		conditions := make([]string, 0, len(ctx.Tags))
 		for _, tag := range ctx.Tags {
-			quotedTags = append(quotedTags, "'"+tag+"'")
+			conditions = append(conditions, sqlTagWithin)
+			args = append(args, tag, tagTreePattern(tag))
 		}
 
-		filter := fmt.Sprintf(" note_tags.tag in (%s)", strings.Join(quotedTags, ","))
+		where = append(where, fmt.Sprintf(sqlFilterNotesByTagTree, strings.Join(conditions, " OR ")))
+	} else if len(ctx.Tags) > 0 {
+		placeholders := make([]string, 0, len(ctx.Tags))
+		for _, tag := range ctx.Tags {
+			placeholders = append(placeholders, "?")
+			args = append(args, tag)
+		}
 
-		where = append(where, filter)
+		where = append(where, fmt.Sprintf(sqlFilterNotesByTags, strings.Join(placeholders, ",")))
 	}
 
 	if len(ctx.Content) > 0 {
-		where = append(where, " notes.content LIKE '%"+ctx.Content+"%'")
+		where = append(where, "notes.content LIKE ?")
+		args = append(args, "%"+ctx.Content+"%")
+	}
+
+	if len(ctx.Regex) > 0 {
+		where = append(where, sqlFilterNotesByRegex)
+		args = append(args, ctx.Regex)
+	}
+
+	for _, word := range models.FuzzyWords(ctx.Fuzzy) {
+		// Words of one or two letters only have trigrams at their edges, which the
+		// words containing them needn't share, so they're left to MatchFuzzy.
+		if utf8.RuneCountInString(word) < 3 {
+			continue
+		}
+
+		trigrams := models.Trigrams(word)
+
+		placeholders := make([]string, 0, len(trigrams))
+		for _, trigram := range trigrams {
+			placeholders = append(placeholders, "?")
+			args = append(args, trigram)
+		}
+
+		where = append(where, fmt.Sprintf(sqlFilterNotesByTrigrams, strings.Join(placeholders, ",")))
+	}
+
+	if len(ctx.Title) > 0 {
+		where = append(where, sqlFilterNotesByTitle)
+		args = append(args, "%"+ctx.Title+"%")
+	}
+
+	if !ctx.DueBefore.IsZero() {
+		where = append(where, sqlFilterNotesByDue)
+		args = append(args, ctx.DueBefore)
+	}
+
+	if ctx.Incomplete {
+		where = append(where, sqlFilterNotesIncomplete)
+	}
+
+	if len(ctx.Status) > 0 {
+		placeholders := make([]string, 0, len(ctx.Status))
+		for _, status := range ctx.Status {
+			placeholders = append(placeholders, "?")
+			args = append(args, string(status))
+		}
+
+		where = append(where, fmt.Sprintf(sqlFilterNotesByStatus, strings.Join(placeholders, ",")))
+	}
+
+	if ctx.Pinned {
+		where = append(where, sqlFilterNotesPinned)
+	}
+
+	for _, filter := range ctx.Meta {
+		condition, conditionArgs := buildMetaFilter(filter)
+		where = append(where, fmt.Sprintf(sqlFilterNotesByMeta, condition))
+		args = append(args, filter.Key)
+		args = append(args, conditionArgs...)
 	}
 
 	whereClauseString := ""
 	if len(where) > 0 {
-		whereClauseString = "WHERE " + strings.Join(where, "AND")
+		whereClauseString = "WHERE " + strings.Join(where, " AND ")
 	}
 
-	return fmt.Sprintf(sqlSearchNotes, whereClauseString)
+	return fmt.Sprintf(sqlSearchNotes, whereClauseString), args
 }
 
-func makeInsertValuesForTags(noteId string, tags []string) string {
+// A LIKE pattern matching every tag beneath a tag.
+func tagTreePattern(tag string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return escaper.Replace(tag) + models.TagSeparator + "_%"
+}
+
+// The condition a metadata row must meet for a filter, following its key.
+func buildMetaFilter(filter models.MetaFilter) (string, []interface{}) {
+	switch filter.Operator {
+	case models.MetaEquals:
+		return " AND value = ? COLLATE NOCASE", []interface{}{filter.Value}
+	case models.MetaNotEquals:
+		return " AND value != ? COLLATE NOCASE", []interface{}{filter.Value}
+	}
+
+	if filter.IsNumeric() {
+		number, _ := models.ParseMetaNumber(filter.Value)
+		return " AND number " + filter.Operator + " ?", []interface{}{number}
+	}
+
+	return "", nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Scan a row beginning with sqlNoteColumns into a note, followed by any extra columns.
+func scanNote(row rowScanner, note *models.Note, extra ...interface{}) error {
+	var updatedAt, due, completedAt sql.NullTime
+	var metaString, status string
+
+	dest := append([]interface{}{&note.ID, &note.Timestamp, &note.Content, &note.Title, &updatedAt, &due, &status, &completedAt, &note.Pinned, &metaString}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+
+	note.UpdatedAt = updatedAt.Time
+	note.Due = due.Time
+	note.Status = models.Status(status)
+	note.CompletedAt = completedAt.Time
+
+	note.Meta = splitMeta(metaString)
+
+	return nil
+}
+
+// Store zero times as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// Split aggregated metadata into a map. Pairs are separated by the unit separator,
+// and keys from values by the record separator.
+func splitMeta(metaString string) map[string]string {
+	if len(metaString) == 0 {
+		return nil
+	}
+
+	meta := make(map[string]string)
+	for _, pair := range strings.Split(metaString, "\x1f") {
+		if separator := strings.Index(pair, "\x1e"); separator != -1 {
+			meta[pair[:separator]] = pair[separator+1:]
+		}
+	}
+
+	return meta
+}
+
+// Split an aggregated tag string into individual tags.
+func splitTags(tagString string) []string {
+	if len(tagString) == 0 {
+		return nil
+	}
+
+	return strings.Split(tagString, ",")
+}
+
+func makeInsertValuesForTags(noteId string, tags []string) (string, []interface{}) {
 	inserts := make([]string, 0, len(tags))
+	args := make([]interface{}, 0, len(tags)*2)
 
 	for _, tag := range tags {
-		inserts = append(inserts, fmt.Sprintf("('%s', '%s')", noteId, tag))
+		inserts = append(inserts, "(?, ?)")
+		args = append(args, noteId, tag)
 	}
 
-	return strings.Join(inserts, ",")
+	return strings.Join(inserts, ","), args
+}
+
+func (repository sqlRepository) WriteNote(ctx context.Context, note models.Note) error {
+	return repository.transaction(ctx, func(tx *sql.Tx) error {
+		return repository.writeNoteWithDetails(ctx, tx, note)
+	})
 }
 
-func (repository sqlRepository) WriteNote(note models.Note) error {
-	return repository.transaction(func(tx *sql.Tx) error {
-		if err := repository.writeNote(tx, note); err != nil {
+// Write a note along with its tags, metadata, links and trigrams.
+func (repository sqlRepository) writeNoteWithDetails(ctx context.Context, tx *sql.Tx, note models.Note) error {
+	if err := repository.writeNote(ctx, tx, note); err != nil {
+		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.Code == sqlite3.ErrConstraint {
+			return ErrNoteExists
+		}
+
+		return err
+	}
+
+	if note.Tags != nil && len(note.Tags) > 0 {
+		if err := repository.writeNoteTags(ctx, tx, note.ID, note.Tags); err != nil {
 			return err
 		}
+	}
 
-		if note.Tags != nil && len(note.Tags) > 0 {
-			if err := repository.writeNoteTags(tx, note.ID, note.Tags); err != nil {
-				return err
-			}
+	if err := repository.writeNoteMeta(ctx, tx, note.ID, note.Meta); err != nil {
+		return err
+	}
+
+	if err := writeNoteLinks(ctx, tx, note.ID, note.Content); err != nil {
+		return err
+	}
+
+	return writeNoteTrigrams(ctx, tx, note.ID, note.Content)
+}
+
+// Store the trigrams of a note's content, see models.Trigrams.
+func writeNoteTrigrams(ctx context.Context, tx *sql.Tx, noteId string, content string) error {
+	trigrams := models.Trigrams(content)
+	if len(trigrams) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, sqlInsertNoteTrigram)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, trigram := range trigrams {
+		if _, err := stmt.ExecContext(ctx, noteId, trigram); err != nil {
+			return err
 		}
+	}
 
+	return nil
+}
+
+// Store the links written in a note's content.
+func writeNoteLinks(ctx context.Context, tx *sql.Tx, noteId string, content string) error {
+	targets := models.ExtractLinks(content)
+	if len(targets) == 0 {
 		return nil
-	})
+	}
+
+	stmt, err := tx.PrepareContext(ctx, sqlInsertNoteLink)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, target := range targets {
+		if _, err := stmt.ExecContext(ctx, noteId, target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repository sqlRepository) writeNoteMeta(ctx context.Context, tx *sql.Tx, noteId string, meta map[string]string) error {
+	if len(meta) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, sqlInsertNoteMeta)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for key, value := range meta {
+		var number interface{}
+		if parsed, isNumber := models.ParseMetaNumber(value); isNumber {
+			number = parsed
+		}
+
+		if _, err := stmt.ExecContext(ctx, noteId, key, value, number); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (repository sqlRepository) writeNote(tx *sql.Tx, note models.Note) error {
-	noteInsertStatement, err := tx.Prepare(sqlInsertNote)
+func (repository sqlRepository) writeNote(ctx context.Context, tx *sql.Tx, note models.Note) error {
+	noteInsertStatement, err := tx.PrepareContext(ctx, sqlInsertNote)
 	if err != nil {
 		return err
 	}
 	defer noteInsertStatement.Close()
 
-	if _, err = noteInsertStatement.Exec(note.ID, note.Timestamp, note.Content); err != nil {
+	if _, err = noteInsertStatement.ExecContext(ctx, note.ID, note.Timestamp, note.Content, note.Title, nullTime(note.Due),
+		string(note.Status), nullTime(note.CompletedAt), note.Pinned); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (repository sqlRepository) TagNote(note models.Note, tags []string) error {
-	return repository.transaction(func(tx *sql.Tx) error {
-		if err := repository.deleteNoteTags(tx, note.ID); err != nil {
+func (repository sqlRepository) TagNote(ctx context.Context, note models.Note, tags []string) error {
+	return repository.transaction(ctx, func(tx *sql.Tx) error {
+		// Tagging a missing note would leave orphaned tags behind.
+		var exists int
+		if err := tx.QueryRowContext(ctx, sqlNoteExists, note.ID).Scan(&exists); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNoteNotFound
+			}
+
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, sqlTouchNote, time.Now(), note.ID); err != nil {
+			return err
+		}
+
+		if err := repository.deleteNoteTags(ctx, tx, note.ID); err != nil {
 			return err
 		}
 
-		if err := repository.writeNoteTags(tx, note.ID, tags); err != nil {
+		if len(tags) == 0 {
+			return nil
+		}
+
+		if err := repository.writeNoteTags(ctx, tx, note.ID, tags); err != nil {
 			return err
 		}
 
@@ -117,28 +372,49 @@ func (repository sqlRepository) TagNote(note models.Note, tags []string) error {
 	})
 }
 
-func (repository sqlRepository) writeNoteTags(tx *sql.Tx, noteId string, tags []string) error {
-	sqlString := sqlInsertNoteTags + " " + makeInsertValuesForTags(noteId, tags)
-	tagInsertPreparedStatement, err := tx.Prepare(sqlString)
+func (repository sqlRepository) writeNoteTags(ctx context.Context, tx *sql.Tx, noteId string, tags []string) error {
+	values, args := makeInsertValuesForTags(noteId, tags)
+	tagInsertPreparedStatement, err := tx.PrepareContext(ctx, sqlInsertNoteTags+" "+values)
 
 	if err != nil {
 		return err
 	}
 	defer tagInsertPreparedStatement.Close()
 
-	if _, err := tagInsertPreparedStatement.Exec(); err != nil {
+	if _, err := tagInsertPreparedStatement.ExecContext(ctx, args...); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (repository sqlRepository) transaction(callback func(*sql.Tx) error) error {
-	if transaction, err := repository.db.GetConnection().Begin(); err != nil {
+// Run callback inside a transaction, retrying it if the database is busy.
+// The transaction is rolled back if ctx is cancelled before it commits.
+func (repository sqlRepository) transaction(ctx context.Context, callback func(*sql.Tx) error) error {
+	var err error
+
+	for attempt := 1; attempt <= transactionAttempts; attempt++ {
+		if err = repository.tryTransaction(ctx, callback); err == nil || !isBusyError(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * transactionRetryDelay):
+		}
+	}
+
+	return err
+}
+
+func (repository sqlRepository) tryTransaction(ctx context.Context, callback func(*sql.Tx) error) error {
+	if transaction, err := repository.db.GetConnection().BeginTx(ctx, nil); err != nil {
 		return err
 	} else {
 		if err := callback(transaction); err != nil {
-			if rollbackErr := transaction.Rollback(); rollbackErr != nil {
+			// A cancelled context has already rolled the transaction back.
+			if rollbackErr := transaction.Rollback(); rollbackErr != nil && rollbackErr != sql.ErrTxDone {
 				return rollbackErr
 			}
 
@@ -149,65 +425,89 @@ func (repository sqlRepository) transaction(callback func(*sql.Tx) error) error
 	}
 }
 
-func (repository sqlRepository) SearchNotes(ctx models.SearchFilters) ([]*models.Note, error) {
-	stmt, err := repository.db.GetConnection().Prepare(buildSearchQueryFromContext(ctx))
+// Check if an error is transient, caused by another connection holding a lock.
+func isBusyError(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+
+	return false
+}
+
+func (repository sqlRepository) SearchNotes(ctx context.Context, filters models.SearchFilters) ([]*models.Note, error) {
+	var notes []*models.Note
+
+	err := repository.SearchNotesFunc(ctx, filters, func(note *models.Note) error {
+		notes = append(notes, note)
+		return nil
+	})
+
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
 
-	rs, err := stmt.Query()
+	return notes, nil
+}
+
+func (repository sqlRepository) SearchNotesFunc(ctx context.Context, filters models.SearchFilters, fn NoteFunc) error {
+	query, args := buildSearchQueryFromContext(filters)
+
+	rs, err := repository.db.GetConnection().QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer rs.Close()
 
-	var notes []*models.Note
 	for rs.Next() {
 		var note models.Note
 		var tagString string
 
-		if err := rs.Scan(&note.ID, &note.Content, &tagString, &note.Timestamp); err != nil {
-			return nil, err
+		if err := scanNote(rs, &note, &tagString); err != nil {
+			return err
 		}
 
-		if len(tagString) > 0 {
-			if tags, err := repository.getNoteTags(note.ID); err != nil {
-				return nil, err
-			} else {
-				note.Tags = tags
+		note.Tags = splitTags(tagString)
+
+		// Trigrams only narrow down the notes which could match.
+		if len(filters.Fuzzy) > 0 {
+			if _, matches := models.MatchFuzzy(note.Content, filters.Fuzzy); !matches {
+				continue
 			}
 		}
 
-		notes = append(notes, &note)
+		if err := fn(&note); err != nil {
+			return err
+		}
 	}
 
-	return notes, nil
+	return rs.Err()
 }
 
-func (repository sqlRepository) getNoteTags(noteId string) ([]string, error) {
-	if stmt, err := repository.db.GetConnection().Prepare(sqlGetNoteTags); err != nil {
+func (repository sqlRepository) getNoteTags(ctx context.Context, noteId string) ([]string, error) {
+	if stmt, err := repository.db.GetConnection().PrepareContext(ctx, sqlGetNoteTags); err != nil {
 		return nil, err
 	} else {
 		defer stmt.Close()
 
 		var tagString string
-		if err := stmt.QueryRow(noteId).Scan(&tagString); err != nil {
+		if err := stmt.QueryRowContext(ctx, noteId).Scan(&tagString); err != nil {
 			return nil, err
 		} else {
-			return strings.Split(tagString, ","), nil
+			return splitTags(tagString), nil
 		}
 	}
 }
 
-func (repository sqlRepository) DeleteNote(noteId string) error {
-	return repository.transaction(func(tx *sql.Tx) error {
-		stmt, err := tx.Prepare(sqlDeleteNote)
+func (repository sqlRepository) DeleteNote(ctx context.Context, noteId string) error {
+	return repository.transaction(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, sqlDeleteNote)
 		if err != nil {
 			return err
 		}
 		defer stmt.Close()
 
-		if rs, err := stmt.Exec(noteId); err != nil {
+		if rs, err := stmt.ExecContext(ctx, noteId); err != nil {
 			return err
 		} else {
 			numDeleted, err := rs.RowsAffected()
@@ -220,16 +520,33 @@ func (repository sqlRepository) DeleteNote(noteId string) error {
 			}
 		}
 
-		return repository.deleteNoteTags(tx, noteId)
+		if _, err := tx.ExecContext(ctx, sqlDeleteNoteMeta, noteId); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, sqlDeleteNoteAttachments, noteId); err != nil {
+			return err
+		}
+
+		// Links to the note are kept, and show as broken.
+		if _, err := tx.ExecContext(ctx, sqlDeleteNoteLinks, noteId); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, sqlDeleteNoteTrigrams, noteId); err != nil {
+			return err
+		}
+
+		return repository.deleteNoteTags(ctx, tx, noteId)
 	})
 }
 
-func (repository sqlRepository) deleteNoteTags(tx *sql.Tx, noteId string) error {
-	if stmt, err := tx.Prepare(sqlDeleteNoteTags); err != nil {
+func (repository sqlRepository) deleteNoteTags(ctx context.Context, tx *sql.Tx, noteId string) error {
+	if stmt, err := tx.PrepareContext(ctx, sqlDeleteNoteTags); err != nil {
 		return err
 	} else {
 		defer stmt.Close()
-		if _, err = stmt.Exec(noteId); err != nil {
+		if _, err = stmt.ExecContext(ctx, noteId); err != nil {
 			return err
 		}
 	}
@@ -237,12 +554,59 @@ func (repository sqlRepository) deleteNoteTags(tx *sql.Tx, noteId string) error
 	return nil
 }
 
+func (repository sqlRepository) ListTags(ctx context.Context) ([]models.TagCount, error) {
+	rs, err := repository.db.GetConnection().QueryContext(ctx, sqlListTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	tags := make([]models.TagCount, 0)
+	for rs.Next() {
+		var tag models.TagCount
+		if err := rs.Scan(&tag.Tag, &tag.Count); err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, rs.Err()
+}
+
+func (repository sqlRepository) RenameTag(ctx context.Context, from string, to string) (int, error) {
+	var noteCount int
+
+	err := repository.transaction(ctx, func(tx *sql.Tx) error {
+		pattern := tagTreePattern(from)
+
+		if err := tx.QueryRowContext(ctx, sqlCountNotesWithinTag, from, pattern).Scan(&noteCount); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, sqlRenameTags, to, utf8.RuneCountInString(from)+1, from, pattern); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, sqlDeleteDuplicateTags)
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return noteCount, nil
+}
+
 // Update a note's content
-func (repository sqlRepository) UpdateNoteContent(noteId string, content string) error {
-	if stmt, err := repository.db.GetConnection().Prepare(sqlUpdateNote); err != nil {
+func (repository sqlRepository) UpdateNoteContent(ctx context.Context, noteId string, content string) error {
+	if stmt, err := repository.db.GetConnection().PrepareContext(ctx, sqlUpdateNote); err != nil {
 		return err
 	} else {
-		if updateResult, err := stmt.Exec(content, noteId); err != nil {
+		defer stmt.Close()
+
+		if updateResult, err := stmt.ExecContext(ctx, content, noteId); err != nil {
 			return err
 		} else if count, err := updateResult.RowsAffected(); err != nil {
 			return err
@@ -255,22 +619,24 @@ func (repository sqlRepository) UpdateNoteContent(noteId string, content string)
 }
 
 // Get a single note from the database.
-func (repository sqlRepository) LookupNote(noteId string) (*models.Note, error) {
-	return repository.getNote(noteId, false)
+func (repository sqlRepository) LookupNote(ctx context.Context, noteId string) (*models.Note, error) {
+	return repository.getNote(ctx, noteId, false)
 }
 
-func (repository sqlRepository) LookupNoteWithTags(noteId string) (*models.Note, error) {
-	return repository.getNote(noteId, true)
+func (repository sqlRepository) LookupNoteWithTags(ctx context.Context, noteId string) (*models.Note, error) {
+	return repository.getNote(ctx, noteId, true)
 }
 
-func (repository sqlRepository) getNote(noteId string, withTags bool) (*models.Note, error) {
-	if stmt, err := repository.db.GetConnection().Prepare(sqlGetNote); err != nil {
+func (repository sqlRepository) getNote(ctx context.Context, noteId string, withTags bool) (*models.Note, error) {
+	if stmt, err := repository.db.GetConnection().PrepareContext(ctx, sqlGetNote); err != nil {
 		return nil, err
 	} else {
+		defer stmt.Close()
+
 		var note models.Note
 
-		record := stmt.QueryRow(noteId)
-		err := record.Scan(&note.ID, &note.Timestamp, &note.Content)
+		record := stmt.QueryRowContext(ctx, noteId)
+		err := scanNote(record, &note)
 		if err != nil {
 			if err == sql.ErrNoRows { // This is fine.
 				return nil, ErrNoteNotFound
@@ -280,7 +646,7 @@ func (repository sqlRepository) getNote(noteId string, withTags bool) (*models.N
 		}
 
 		if withTags {
-			if tags, err := repository.getNoteTags(noteId); err != nil {
+			if tags, err := repository.getNoteTags(ctx, noteId); err != nil {
 				return nil, err
 			} else {
 				note.Tags = tags
@@ -291,27 +657,352 @@ func (repository sqlRepository) getNote(noteId string, withTags bool) (*models.N
 	}
 }
 
-func (repository sqlRepository) UpdateNote(note models.Note) error {
-	if stmt, err := repository.db.GetConnection().Prepare(sqlUpdateNoteContent); err != nil {
-		return err
-	} else {
-		if results, err := stmt.Exec(note.Content, note.ID); err != nil {
+// Update a note's content, title and metadata.
+func (repository sqlRepository) UpdateNote(ctx context.Context, note models.Note) error {
+	return repository.transaction(ctx, func(tx *sql.Tx) error {
+		if results, err := tx.ExecContext(ctx, sqlUpdateNoteContent, note.Content, note.Title, nullTime(note.Due), time.Now(), note.ID); err != nil {
 			return err
 		} else if rows, err := results.RowsAffected(); err != nil {
 			return err
 		} else if rows == 0 {
 			return ErrFailedToUpdateNote
 		}
+
+		if _, err := tx.ExecContext(ctx, sqlDeleteNoteMeta, note.ID); err != nil {
+			return err
+		}
+
+		if err := repository.writeNoteMeta(ctx, tx, note.ID, note.Meta); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, sqlDeleteNoteLinks, note.ID); err != nil {
+			return err
+		}
+
+		if err := writeNoteLinks(ctx, tx, note.ID, note.Content); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, sqlDeleteNoteTrigrams, note.ID); err != nil {
+			return err
+		}
+
+		return writeNoteTrigrams(ctx, tx, note.ID, note.Content)
+	})
+}
+
+func (repository sqlRepository) SetNoteStatus(ctx context.Context, noteId string, status models.Status, at time.Time) error {
+	var completedAt time.Time
+	if status.IsClosed() {
+		completedAt = at
 	}
 
-	return nil
+	return repository.transaction(ctx, func(tx *sql.Tx) error {
+		results, err := tx.ExecContext(ctx, sqlSetNoteStatus, string(status), nullTime(completedAt), time.Now(), noteId)
+		if err != nil {
+			return err
+		}
+
+		if rows, err := results.RowsAffected(); err != nil {
+			return err
+		} else if rows == 0 {
+			return ErrNoteNotFound
+		}
+
+		return nil
+	})
+}
+
+func (repository sqlRepository) PinNote(ctx context.Context, noteId string, pinned bool) error {
+	return repository.transaction(ctx, func(tx *sql.Tx) error {
+		results, err := tx.ExecContext(ctx, sqlPinNote, pinned, noteId)
+		if err != nil {
+			return err
+		}
+
+		if rows, err := results.RowsAffected(); err != nil {
+			return err
+		} else if rows == 0 {
+			return ErrNoteNotFound
+		}
+
+		return nil
+	})
+}
+
+func (repository sqlRepository) Links(ctx context.Context, noteId string) ([]models.Link, error) {
+	connection := repository.db.GetConnection()
+
+	var exists int
+	if err := connection.QueryRowContext(ctx, sqlNoteExists, noteId).Scan(&exists); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNoteNotFound
+		}
+
+		return nil, err
+	}
+
+	rs, err := connection.QueryContext(ctx, sqlGetNoteLinks, noteId)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []string
+	for rs.Next() {
+		var target string
+		if err := rs.Scan(&target); err != nil {
+			rs.Close()
+			return nil, err
+		}
+
+		targets = append(targets, target)
+	}
+	rs.Close()
+
+	if err := rs.Err(); err != nil {
+		return nil, err
+	}
+
+	links := make([]models.Link, 0, len(targets))
+	for _, target := range targets {
+		link, err := repository.resolveLink(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+func (repository sqlRepository) resolveLink(ctx context.Context, target string) (models.Link, error) {
+	link := models.Link{Target: target}
+
+	rs, err := repository.db.GetConnection().QueryContext(ctx, sqlResolveLink, target, target)
+	if err != nil {
+		return link, err
+	}
+	defer rs.Close()
+
+	var matches []*models.Note
+	for rs.Next() {
+		var note models.Note
+		if err := scanNote(rs, &note); err != nil {
+			return link, err
+		}
+
+		matches = append(matches, &note)
+	}
+
+	if err := rs.Err(); err != nil {
+		return link, err
+	}
+
+	if len(matches) == 1 || (len(matches) > 1 && matches[0].ID == target) {
+		link.Note = matches[0]
+	} else {
+		link.Ambiguous = len(matches) > 1
+	}
+
+	return link, nil
+}
+
+func (repository sqlRepository) Backlinks(ctx context.Context, noteId string) ([]*models.Note, error) {
+	query := fmt.Sprintf(sqlSearchNotes, "WHERE "+sqlFilterNotesLinkingTo)
+
+	rs, err := repository.db.GetConnection().QueryContext(ctx, query, noteId)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	notes := make([]*models.Note, 0)
+	for rs.Next() {
+		var note models.Note
+		var tagString string
+
+		if err := scanNote(rs, &note, &tagString); err != nil {
+			return nil, err
+		}
+
+		note.Tags = splitTags(tagString)
+		notes = append(notes, &note)
+	}
+
+	return notes, rs.Err()
+}
+
+func (repository sqlRepository) LatestNotes(ctx context.Context, limit int) ([]*models.Note, error) {
+	rs, err := repository.db.GetConnection().QueryContext(ctx, sqlLatestNotes, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	notes := make([]*models.Note, 0, limit)
+	for rs.Next() {
+		var note models.Note
+
+		if err := scanNote(rs, &note); err != nil {
+			return nil, err
+		}
+
+		notes = append(notes, &note)
+	}
+
+	return notes, rs.Err()
 }
 
-// Initialize the database structure.
+// Initialize the database structure, creating the schema and migrating it. Retries if the database is
+// busy, since switching a new database to WAL mode as it's opened can fail while another process is creating it.
 func (repository sqlRepository) initialize(db *sql.DB) error {
-	if _, err := db.Exec(sqlInitializeDatabase); err != nil {
+	var err error
+
+	for attempt := 1; attempt <= transactionAttempts; attempt++ {
+		if err = repository.migrate(db); err == nil || !isBusyError(err) {
+			return err
+		}
+
+		time.Sleep(time.Duration(attempt) * transactionRetryDelay)
+	}
+
+	return err
+}
+
+// Changes to existing data which can't be written in SQL, keyed by the number of
+// the migration they run after.
+var dataMigrations = map[int]func(tx *sql.Tx) error{
+	3: normalizeTags,
+	4: indexLinks,
+	9: indexTrigrams,
+}
+
+// Store the links written in existing notes.
+func indexLinks(tx *sql.Tx) error {
+	return indexNoteContent(tx, writeNoteLinks)
+}
+
+// Store the trigrams of existing notes.
+func indexTrigrams(tx *sql.Tx) error {
+	return indexNoteContent(tx, writeNoteTrigrams)
+}
+
+// Index the content of every note with a function, e.g writeNoteLinks.
+func indexNoteContent(tx *sql.Tx, index func(ctx context.Context, tx *sql.Tx, noteId string, content string) error) error {
+	rs, err := tx.Query(sqlListNoteContent)
+	if err != nil {
 		return err
 	}
 
+	contents := make(map[string]string)
+	for rs.Next() {
+		var noteId, content string
+		if err := rs.Scan(&noteId, &content); err != nil {
+			rs.Close()
+			return err
+		}
+
+		contents[noteId] = content
+	}
+	rs.Close()
+
+	if err := rs.Err(); err != nil {
+		return err
+	}
+
+	for noteId, content := range contents {
+		if err := index(context.Background(), tx, noteId, content); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// Apply the tag policy to existing tags, see models.NormalizeTag.
+func normalizeTags(tx *sql.Tx) error {
+	rs, err := tx.Query(sqlListDistinctTags)
+	if err != nil {
+		return err
+	}
+
+	var tags []string
+	for rs.Next() {
+		var tag string
+		if err := rs.Scan(&tag); err != nil {
+			rs.Close()
+			return err
+		}
+
+		tags = append(tags, tag)
+	}
+	rs.Close()
+
+	if err := rs.Err(); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		if normalized := models.NormalizeTag(tag); normalized != tag {
+			if _, err := tx.Exec(sqlReplaceTag, normalized, tag); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Tags left empty by normalizing were only whitespace.
+	if _, err := tx.Exec(sqlDeleteEmptyTags); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(sqlDeleteDuplicateTags)
+	return err
+}
+
+// Apply any schema migrations the database hasn't had yet.
+func (repository sqlRepository) migrate(db *sql.DB) error {
+	// Transactions take the write lock as they begin, so concurrent processes
+	// can't both apply the same migration.
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The schema is created in the same transaction, since statements outside of one
+	// can fail immediately while another process is creating it.
+	if _, err := tx.Exec(sqlInitializeDatabase); err != nil {
+		return err
+	}
+
+	var version int
+	if err := tx.QueryRow(sqlGetSchemaVersion).Scan(&version); err != nil {
+		return err
+	}
+
+	if version >= len(sqlMigrations) {
+		return nil
+	}
+
+	for i, migration := range sqlMigrations[version:] {
+		number := version + i + 1
+
+		if _, err := tx.Exec(migration); err != nil {
+			return fmt.Errorf("migration %d: %w", number, err)
+		}
+
+		if dataMigration, exists := dataMigrations[number]; exists {
+			if err := dataMigration(tx); err != nil {
+				return fmt.Errorf("migration %d: %w", number, err)
+			}
+		}
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf(sqlSetSchemaVersion, len(sqlMigrations))); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
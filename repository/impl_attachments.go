@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/mattn/go-sqlite3"
+	"github.com/ricanontherun/short-form/models"
+)
+
+func (repository sqlRepository) WriteAttachments(ctx context.Context, attachments []models.Attachment) error {
+	return repository.transaction(ctx, func(tx *sql.Tx) error {
+		for _, attachment := range attachments {
+			if err := writeAttachment(ctx, tx, attachment); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (repository sqlRepository) WriteNoteWithAttachments(ctx context.Context, note models.Note, attachments []models.Attachment) error {
+	return repository.transaction(ctx, func(tx *sql.Tx) error {
+		if err := repository.writeNoteWithDetails(ctx, tx, note); err != nil {
+			return err
+		}
+
+		for _, attachment := range attachments {
+			if err := writeAttachment(ctx, tx, attachment); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func writeAttachment(ctx context.Context, tx *sql.Tx, attachment models.Attachment) error {
+	var exists int
+	if err := tx.QueryRowContext(ctx, sqlNoteExists, attachment.NoteID).Scan(&exists); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNoteNotFound
+		}
+
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, sqlInsertAttachment,
+		attachment.NoteID,
+		attachment.Name,
+		attachment.Size,
+		attachment.Hash,
+		attachment.Data,
+		attachment.Timestamp,
+	)
+
+	if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.Code == sqlite3.ErrConstraint {
+		return ErrAttachmentExists
+	}
+
+	return err
+}
+
+func (repository sqlRepository) ListAttachments(ctx context.Context, noteId string) ([]models.Attachment, error) {
+	rs, err := repository.db.GetConnection().QueryContext(ctx, sqlListAttachments, noteId)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	attachments := make([]models.Attachment, 0)
+	for rs.Next() {
+		var attachment models.Attachment
+		if err := rs.Scan(&attachment.NoteID, &attachment.Name, &attachment.Size, &attachment.Hash, &attachment.Timestamp); err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rs.Err()
+}
+
+func (repository sqlRepository) ReadAttachment(ctx context.Context, noteId string, name string) (*models.Attachment, error) {
+	var attachment models.Attachment
+
+	err := repository.db.GetConnection().QueryRowContext(ctx, sqlGetAttachment, noteId, name).Scan(
+		&attachment.NoteID,
+		&attachment.Name,
+		&attachment.Size,
+		&attachment.Hash,
+		&attachment.Timestamp,
+		&attachment.Data,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAttachmentNotFound
+		}
+
+		return nil, err
+	}
+
+	return &attachment, nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSqlRepository_Attachments(t *testing.T) {
+	repo, cleanup := newTestSqlRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	note := models.NewNote(nil, "screenshot of the error")
+	assert.Nil(t, repo.WriteNote(ctx, note))
+
+	screenshot := models.NewAttachment(note.ID, "error.png", []byte{0x89, 'P', 'N', 'G', 0x00, 0x01})
+	log := models.NewAttachment(note.ID, "app.log", []byte("panic: oops\n"))
+	assert.Nil(t, repo.WriteAttachments(ctx, []models.Attachment{screenshot}))
+	assert.Nil(t, repo.WriteAttachments(ctx, []models.Attachment{log}))
+
+	// Attachments are written together, so the first isn't kept when the second already exists.
+	trace := models.NewAttachment(note.ID, "trace.txt", []byte("goroutine 1"))
+	assert.EqualValues(t, ErrAttachmentExists, repo.WriteAttachments(ctx, []models.Attachment{trace, log}))
+	assert.EqualValues(t, ErrNoteNotFound, repo.WriteAttachments(ctx, []models.Attachment{models.NewAttachment("missing", "a.txt", []byte("a"))}))
+
+	attachments, err := repo.ListAttachments(ctx, note.ID)
+	assert.Nil(t, err)
+	assert.Len(t, attachments, 2)
+	assert.EqualValues(t, "app.log", attachments[0].Name)
+	assert.EqualValues(t, log.Size, attachments[0].Size)
+	assert.EqualValues(t, log.Hash, attachments[0].Hash)
+	assert.Empty(t, attachments[0].Data)
+
+	found, err := repo.ReadAttachment(ctx, note.ID, "error.png")
+	assert.Nil(t, err)
+	assert.EqualValues(t, screenshot.Data, found.Data)
+	assert.EqualValues(t, screenshot.Hash, found.Hash)
+
+	_, err = repo.ReadAttachment(ctx, note.ID, "missing.png")
+	assert.EqualValues(t, ErrAttachmentNotFound, err)
+
+	assert.Nil(t, repo.DeleteNote(ctx, note.ID))
+	attachments, err = repo.ListAttachments(ctx, note.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, attachments)
+}
+
+func TestSqlRepository_WriteNoteWithAttachments(t *testing.T) {
+	repo, cleanup := newTestSqlRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	note := models.NewNote(nil, "crash report")
+	log := models.NewAttachment(note.ID, "app.log", []byte("panic: oops\n"))
+
+	assert.EqualValues(t, ErrAttachmentExists, repo.WriteNoteWithAttachments(ctx, note, []models.Attachment{log, log}))
+	_, err := repo.LookupNote(ctx, note.ID)
+	assert.EqualValues(t, ErrNoteNotFound, err)
+
+	assert.Nil(t, repo.WriteNoteWithAttachments(ctx, note, []models.Attachment{log}))
+	attachments, err := repo.ListAttachments(ctx, note.ID)
+	assert.Nil(t, err)
+	assert.Len(t, attachments, 1)
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/ricanontherun/short-form/models"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+const benchmarkNoteCount = 100000
+
+var (
+	benchmarkOnce    sync.Once
+	benchmarkRepo    sqlRepository
+	benchmarkCleanup func()
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+
+	if benchmarkCleanup != nil {
+		benchmarkCleanup()
+	}
+
+	os.Exit(code)
+}
+
+// Lazily generate a database of benchmarkNoteCount notes, shared by all benchmarks.
+// Every note has 3 tags, 1 of which is shared by a tenth of all notes.
+func getBenchmarkRepository(b *testing.B) sqlRepository {
+	benchmarkOnce.Do(func() {
+		benchmarkRepo, benchmarkCleanup = newTestSqlRepository(b)
+		ctx := context.Background()
+
+		err := benchmarkRepo.transaction(ctx, func(tx *sql.Tx) error {
+			start := time.Now().AddDate(0, 0, -benchmarkNoteCount/100)
+
+			for i := 0; i < benchmarkNoteCount; i++ {
+				note := models.NewNote(
+					[]string{fmt.Sprintf("group-%d", i%10), fmt.Sprintf("tag-%d", i%1000), "generated"},
+					fmt.Sprintf("generated note number %d, with some filler content to search through", i),
+				)
+				note.Timestamp = start.Add(time.Duration(i) * 15 * time.Minute)
+
+				if err := benchmarkRepo.writeNote(ctx, tx, note); err != nil {
+					return err
+				}
+
+				if err := benchmarkRepo.writeNoteTags(ctx, tx, note.ID, note.Tags); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			b.Fatal(err)
+		}
+	})
+
+	return benchmarkRepo
+}
+
+func benchmarkSearchNotes(b *testing.B, filters models.SearchFilters) {
+	repo := getBenchmarkRepository(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.SearchNotes(context.Background(), filters); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// The search read path as it was, fetching each note's tags with a query per row.
+// Kept to measure the single query path against.
+func benchmarkSearchNotesPerRowTags(b *testing.B, filters models.SearchFilters) {
+	repo := getBenchmarkRepository(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		notes, err := repo.SearchNotes(context.Background(), filters)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for _, note := range notes {
+			if note.Tags, err = repo.getNoteTags(context.Background(), note.ID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkSearchNotes_All(b *testing.B) {
+	benchmarkSearchNotes(b, models.SearchFilters{})
+}
+
+func BenchmarkSearchNotes_All_PerRowTags(b *testing.B) {
+	benchmarkSearchNotesPerRowTags(b, models.SearchFilters{})
+}
+
+func BenchmarkSearchNotes_Tag(b *testing.B) {
+	benchmarkSearchNotes(b, models.SearchFilters{Tags: []string{"group-1"}})
+}
+
+func BenchmarkSearchNotes_Tag_PerRowTags(b *testing.B) {
+	benchmarkSearchNotesPerRowTags(b, models.SearchFilters{Tags: []string{"group-1"}})
+}
+
+func BenchmarkSearchNotes_Content(b *testing.B) {
+	benchmarkSearchNotes(b, models.SearchFilters{Content: "number 99"})
+}
+
+func BenchmarkSearchNotes_Content_PerRowTags(b *testing.B) {
+	benchmarkSearchNotesPerRowTags(b, models.SearchFilters{Content: "number 99"})
+}
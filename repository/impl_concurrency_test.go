@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"github.com/ricanontherun/short-form/database"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// Several writers, each with their own connection pool, mimic separate sf
+// processes (e.g `sf stream` and `sf w`) writing to the same database file.
+func TestSqlRepository_ConcurrentWriters(t *testing.T) {
+	const writers = 8
+	const notesPerWriter = 25
+
+	dir, err := ioutil.TempDir("", "short-form")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "data.db")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*notesPerWriter)
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+
+		go func(writer int) {
+			defer wg.Done()
+
+			db := database.NewDatabase(path, database.DefaultOptions())
+			defer db.Close()
+
+			repo, err := NewSqlRepository(db)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			for n := 0; n < notesPerWriter; n++ {
+				note := models.NewNote([]string{fmt.Sprintf("writer-%d", writer), "stress"}, fmt.Sprintf("note %d", n))
+
+				if err := repo.WriteNote(context.Background(), note); err != nil {
+					errs <- err
+				}
+
+				if _, err := repo.SearchNotes(context.Background(), models.SearchFilters{Tags: []string{"stress"}}); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	db := database.NewDatabase(path, database.DefaultOptions())
+	defer db.Close()
+
+	repo, err := NewSqlRepository(db)
+	assert.Nil(t, err)
+
+	notes, err := repo.SearchNotes(context.Background(), models.SearchFilters{})
+	assert.Nil(t, err)
+	assert.Len(t, notes, writers*notesPerWriter)
+}
+
+func TestIsBusyError(t *testing.T) {
+	busy := sqlite3.Error{Code: sqlite3.ErrBusy}
+
+	assert.True(t, isBusyError(busy))
+	assert.True(t, isBusyError(fmt.Errorf("migration 9: %w", busy)))
+	assert.False(t, isBusyError(fmt.Errorf("migration 9: %w", sqlite3.Error{Code: sqlite3.ErrConstraint})))
+	assert.False(t, isBusyError(ErrNoteNotFound))
+}
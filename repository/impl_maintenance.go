@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ricanontherun/short-form/models"
+	"os"
+	"time"
+)
+
+func (repository sqlRepository) CheckIntegrity(ctx context.Context, fix bool) (*models.IntegrityReport, error) {
+	report := models.IntegrityReport{}
+	connection := repository.db.GetConnection()
+
+	rs, err := connection.QueryContext(ctx, sqlIntegrityCheck)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	for rs.Next() {
+		var result string
+		if err := rs.Scan(&result); err != nil {
+			return nil, err
+		}
+
+		if result != "ok" {
+			report.Problems = append(report.Problems, result)
+		}
+	}
+
+	if err := rs.Err(); err != nil {
+		return nil, err
+	}
+
+	orphans, err := repository.findOrphanTags(ctx, connection)
+	if err != nil {
+		return nil, err
+	}
+	report.OrphanTags = orphans
+
+	if fix && len(orphans) > 0 {
+		if err := repository.transaction(ctx, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, sqlDeleteOrphanTags)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+
+		report.Fixed = true
+	}
+
+	return &report, nil
+}
+
+func (repository sqlRepository) findOrphanTags(ctx context.Context, connection *sql.DB) ([]models.OrphanTag, error) {
+	rs, err := connection.QueryContext(ctx, sqlFindOrphanTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	var orphans []models.OrphanTag
+	for rs.Next() {
+		var orphan models.OrphanTag
+		if err := rs.Scan(&orphan.NoteID, &orphan.Tag); err != nil {
+			return nil, err
+		}
+
+		orphans = append(orphans, orphan)
+	}
+
+	return orphans, rs.Err()
+}
+
+func (repository sqlRepository) Vacuum(ctx context.Context) error {
+	_, err := repository.db.GetConnection().ExecContext(ctx, sqlVacuum)
+	return err
+}
+
+func (repository sqlRepository) Optimize(ctx context.Context) error {
+	_, err := repository.db.GetConnection().ExecContext(ctx, sqlOptimize)
+	return err
+}
+
+func (repository sqlRepository) Stats(ctx context.Context, largestNotes int) (*models.Stats, error) {
+	connection := repository.db.GetConnection()
+	stats := models.Stats{}
+
+	if err := connection.QueryRowContext(ctx, sqlCountNotes).Scan(&stats.NoteCount); err != nil {
+		return nil, err
+	}
+
+	if err := connection.QueryRowContext(ctx, sqlCountTags).Scan(&stats.TagCount); err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Stat(repository.db.GetPath()); err != nil {
+		return nil, err
+	} else {
+		stats.FileSize = info.Size()
+	}
+
+	// In WAL mode recent writes are in the -wal file until they're checkpointed.
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if info, err := os.Stat(repository.db.GetPath() + suffix); err == nil {
+			stats.FileSize += info.Size()
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	if stats.NoteCount == 0 {
+		return &stats, nil
+	}
+
+	var oldest, newest time.Time
+	if err := connection.QueryRowContext(ctx, sqlOldestNoteTimestamp).Scan(&oldest); err != nil {
+		return nil, err
+	}
+	if err := connection.QueryRowContext(ctx, sqlNewestNoteTimestamp).Scan(&newest); err != nil {
+		return nil, err
+	}
+	stats.OldestNote = &oldest
+	stats.NewestNote = &newest
+
+	rs, err := connection.QueryContext(ctx, sqlLargestNotes, largestNotes)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	for rs.Next() {
+		var note models.Note
+		if err := scanNote(rs, &note); err != nil {
+			return nil, err
+		}
+
+		stats.LargestNotes = append(stats.LargestNotes, &note)
+	}
+
+	return &stats, rs.Err()
+}
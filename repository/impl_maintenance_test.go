@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"github.com/ricanontherun/short-form/database"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Create a sql repository backed by a fresh database file.
+func newTestSqlRepository(t testing.TB) (sqlRepository, func()) {
+	dir, err := ioutil.TempDir("", "short-form")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := database.NewDatabase(filepath.Join(dir, "data.db"), database.DefaultOptions())
+	repo, err := NewSqlRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return repo.(sqlRepository), func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSqlRepository_CheckIntegrity(t *testing.T) {
+	repo, cleanup := newTestSqlRepository(t)
+	defer cleanup()
+
+	note := models.NewNote([]string{"git"}, "git rebase -i")
+	assert.Nil(t, repo.WriteNote(context.Background(), note))
+
+	// Simulate a tag left behind by a note which no longer exists.
+	_, err := repo.db.GetConnection().Exec(sqlDeleteNote, note.ID)
+	assert.Nil(t, err)
+
+	report, err := repo.CheckIntegrity(context.Background(), false)
+	assert.Nil(t, err)
+	assert.False(t, report.Ok())
+	assert.Empty(t, report.Problems)
+	assert.EqualValues(t, []models.OrphanTag{{NoteID: note.ID, Tag: "git"}}, report.OrphanTags)
+
+	report, err = repo.CheckIntegrity(context.Background(), true)
+	assert.Nil(t, err)
+	assert.True(t, report.Ok())
+
+	report, err = repo.CheckIntegrity(context.Background(), false)
+	assert.Nil(t, err)
+	assert.Empty(t, report.OrphanTags)
+}
+
+func TestSqlRepository_Stats(t *testing.T) {
+	repo, cleanup := newTestSqlRepository(t)
+	defer cleanup()
+
+	stats, err := repo.Stats(context.Background(), 5)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, stats.NoteCount)
+	assert.Nil(t, stats.OldestNote)
+
+	small := models.NewNote([]string{"git", "cli"}, "small")
+	large := models.NewNote([]string{"git"}, "a much larger note")
+	assert.Nil(t, repo.WriteNote(context.Background(), small))
+	assert.Nil(t, repo.WriteNote(context.Background(), large))
+
+	stats, err = repo.Stats(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, stats.NoteCount)
+	assert.EqualValues(t, 2, stats.TagCount)
+	assert.True(t, stats.FileSize > 0)
+
+	// Writes which haven't been checkpointed yet are counted from the write-ahead log.
+	wal, err := os.Stat(repo.db.GetPath() + "-wal")
+	assert.Nil(t, err)
+	assert.True(t, wal.Size() > 0)
+	file, err := os.Stat(repo.db.GetPath())
+	assert.Nil(t, err)
+	assert.True(t, stats.FileSize >= file.Size()+wal.Size())
+	assert.NotNil(t, stats.OldestNote)
+	assert.NotNil(t, stats.NewestNote)
+	assert.Len(t, stats.LargestNotes, 1)
+	assert.EqualValues(t, large.ID, stats.LargestNotes[0].ID)
+}
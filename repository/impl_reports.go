@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"github.com/ricanontherun/short-form/models"
+	"time"
+)
+
+func (repository sqlRepository) Report(ctx context.Context, since time.Time, until time.Time) (*models.Report, error) {
+	connection := repository.db.GetConnection()
+	report := models.Report{Since: since, Until: until}
+
+	if err := connection.QueryRowContext(ctx, sqlReportTotals, since, until).Scan(&report.NoteCount, &report.WordCount); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	if err := queryCounts(ctx, repository, sqlReportDays, since, until, func(day string, count int) {
+		counts[day] = count
+	}); err != nil {
+		return nil, err
+	}
+
+	// Days are grouped in local time, see sqlReportDays.
+	for day := startOfLocalDay(since); !day.After(until); day = day.AddDate(0, 0, 1) {
+		key := day.Format(models.DailyDateFormat)
+		report.Days = append(report.Days, models.DayCount{Day: key, Count: counts[key]})
+	}
+
+	rs, err := connection.QueryContext(ctx, sqlReportHours, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	for rs.Next() {
+		var hour, count int
+		if err := rs.Scan(&hour, &count); err != nil {
+			return nil, err
+		}
+
+		if hour >= 0 && hour < len(report.Hours) {
+			report.Hours[hour] = count
+		}
+	}
+
+	if err := rs.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := queryCounts(ctx, repository, sqlReportTags, since, until, func(tag string, count int) {
+		report.Tags = append(report.Tags, models.TagCount{Tag: tag, Count: count})
+	}); err != nil {
+		return nil, err
+	}
+
+	report.TopTags = report.Tags
+	if len(report.TopTags) > models.ReportTopTags {
+		report.TopTags = report.TopTags[:models.ReportTopTags]
+	}
+
+	report.LongestStreak = models.LongestStreak(report.Days)
+
+	return &report, nil
+}
+
+// Run a query returning a key and a count per row.
+func queryCounts(ctx context.Context, repository sqlRepository, query string, since time.Time, until time.Time, fn func(key string, count int)) error {
+	rs, err := repository.db.GetConnection().QueryContext(ctx, query, since, until)
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+
+	for rs.Next() {
+		var key string
+		var count int
+		if err := rs.Scan(&key, &count); err != nil {
+			return err
+		}
+
+		fn(key, count)
+	}
+
+	return rs.Err()
+}
+
+func startOfLocalDay(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
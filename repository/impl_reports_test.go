@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSqlRepository_Report(t *testing.T) {
+	repo, cleanup := newTestSqlRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	today := time.Now().Local()
+	day := func(daysAgo int, hour int) time.Time {
+		date := today.AddDate(0, 0, -daysAgo)
+		return time.Date(date.Year(), date.Month(), date.Day(), hour, 30, 0, 0, time.Local)
+	}
+
+	writeNoteAt(t, repo, day(4, 9), []string{"work"}, "standup  ran\n\nlong")
+	writeNoteAt(t, repo, day(3, 9), []string{"work", "infra"}, "rotated the certificates")
+	writeNoteAt(t, repo, day(3, 14), []string{"infra"}, "   ")
+	writeNoteAt(t, repo, day(2, 14), nil, "lunch")
+	writeNoteAt(t, repo, day(0, 22), []string{"home"}, "fixed the\tsink"+strings.Repeat(" ", 20)+"again")
+
+	// Too old to be reported.
+	writeNoteAt(t, repo, day(10, 9), []string{"old"}, "ignored note")
+
+	// Written in another time zone, but on the same local day.
+	other := models.NewNote([]string{"work"}, "review")
+	other.Timestamp = day(0, 8).In(time.FixedZone("UTC+10", 10*60*60))
+	assert.Nil(t, repo.WriteNote(ctx, other))
+
+	since := day(4, 0)
+	report, err := repo.Report(ctx, since, day(0, 23))
+	assert.Nil(t, err)
+
+	assert.EqualValues(t, 6, report.NoteCount)
+	assert.EqualValues(t, 3+3+0+1+4+1, report.WordCount)
+
+	assert.Len(t, report.Days, 5)
+	counts := make([]int, 0, len(report.Days))
+	for _, dayCount := range report.Days {
+		counts = append(counts, dayCount.Count)
+	}
+	assert.EqualValues(t, []int{1, 2, 1, 0, 2}, counts)
+	assert.EqualValues(t, since.Format(models.DailyDateFormat), report.Days[0].Day)
+
+	assert.EqualValues(t, 1, report.Hours[8])
+	assert.EqualValues(t, 2, report.Hours[9])
+	assert.EqualValues(t, 2, report.Hours[14])
+	assert.EqualValues(t, 1, report.Hours[22])
+
+	assert.EqualValues(t, []models.TagCount{
+		{Tag: "work", Count: 3},
+		{Tag: "infra", Count: 2},
+		{Tag: "home", Count: 1},
+	}, report.Tags)
+	assert.EqualValues(t, report.Tags, report.TopTags)
+
+	assert.EqualValues(t, 3, report.LongestStreak.Days)
+	assert.EqualValues(t, report.Days[0].Day, report.LongestStreak.Start)
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/ricanontherun/short-form/database"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestSqlRepository_SearchNotes_ReturnsAllTagsOfMatchingNotes(t *testing.T) {
+	repo, cleanup := newTestSqlRepository(t)
+	defer cleanup()
+
+	tagged := models.NewNote([]string{"git", "cli", "rebase"}, "git rebase -i HEAD~3")
+	untagged := models.NewNote(nil, "no tags here")
+	assert.Nil(t, repo.WriteNote(context.Background(), tagged))
+	assert.Nil(t, repo.WriteNote(context.Background(), untagged))
+
+	notes, err := repo.SearchNotes(context.Background(), models.SearchFilters{Tags: []string{"GIT"}})
+	assert.Nil(t, err)
+	assert.Len(t, notes, 1)
+
+	sort.Strings(notes[0].Tags)
+	assert.EqualValues(t, []string{"cli", "git", "rebase"}, notes[0].Tags)
+
+	notes, err = repo.SearchNotes(context.Background(), models.SearchFilters{Content: "tags"})
+	assert.Nil(t, err)
+	assert.Len(t, notes, 1)
+	assert.EqualValues(t, untagged.ID, notes[0].ID)
+	assert.Empty(t, notes[0].Tags)
+}
+
+func TestSqlRepository_SearchNotes_QuotesAreNotInjected(t *testing.T) {
+	repo, cleanup := newTestSqlRepository(t)
+	defer cleanup()
+
+	assert.Nil(t, repo.WriteNote(context.Background(), models.NewNote([]string{"it's"}, "it's a note")))
+
+	notes, err := repo.SearchNotes(context.Background(), models.SearchFilters{Tags: []string{"it's"}, Content: "it's"})
+	assert.Nil(t, err)
+	assert.Len(t, notes, 1)
+}
+
+func TestSqlRepository_LookupNoteWithTags_Untagged(t *testing.T) {
+	repo, cleanup := newTestSqlRepository(t)
+	defer cleanup()
+
+	note := models.NewNote(nil, "no tags here")
+	assert.Nil(t, repo.WriteNote(context.Background(), note))
+
+	found, err := repo.LookupNoteWithTags(context.Background(), note.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, found.Tags)
+}
+
+func TestSqlRepository_SearchNotesFunc(t *testing.T) {
+	repo, cleanup := newTestSqlRepository(t)
+	defer cleanup()
+
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, repo.WriteNote(context.Background(), models.NewNote(nil, "streamed note")))
+	}
+
+	// An error from the callback stops the search.
+	stop := errors.New("stop")
+	seen := 0
+	err := repo.SearchNotesFunc(context.Background(), models.SearchFilters{}, func(note *models.Note) error {
+		seen++
+		return stop
+	})
+	assert.EqualValues(t, stop, err)
+	assert.EqualValues(t, 1, seen)
+
+	// As does cancelling the context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = repo.SearchNotesFunc(ctx, models.SearchFilters{}, func(note *models.Note) error {
+		return nil
+	})
+	assert.EqualValues(t, context.Canceled, err)
+}
+
+func TestSqlRepository_MigratesExistingDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "short-form")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "data.db")
+	note := models.NewNote(nil, "written before migrations existed")
+	linking := models.NewNote(nil, "links to [["+note.ID+"]]")
+
+	// A database as created before any migrations.
+	connection, err := database.NewDatabaseConnection(path, database.DefaultOptions())
+	assert.Nil(t, err)
+	_, err = connection.Exec(sqlInitializeDatabase)
+	assert.Nil(t, err)
+	for _, existing := range []models.Note{note, linking} {
+		_, err = connection.Exec("INSERT INTO notes (id, timestamp, content) VALUES (?, ?, ?)", existing.ID, existing.Timestamp, existing.Content)
+		assert.Nil(t, err)
+	}
+	for _, tag := range []string{"Git", "git", "Machine  Learning", " "} {
+		_, err = connection.Exec("INSERT INTO note_tags (note_id, tag) VALUES (?, ?)", note.ID, tag)
+		assert.Nil(t, err)
+	}
+	connection.Close()
+
+	db := database.NewDatabase(path, database.DefaultOptions())
+	defer db.Close()
+	repo, err := NewSqlRepository(db)
+	assert.Nil(t, err)
+
+	found, err := repo.LookupNote(context.Background(), note.ID)
+	assert.Nil(t, err)
+	assert.EqualValues(t, note.Content, found.Content)
+	assert.EqualValues(t, "", found.Title)
+
+	found, err = repo.LookupNoteWithTags(context.Background(), note.ID)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"git", "machine-learning"}, found.Tags)
+
+	backlinks, err := repo.Backlinks(context.Background(), note.ID)
+	assert.Nil(t, err)
+	assert.Len(t, backlinks, 1)
+	assert.EqualValues(t, linking.ID, backlinks[0].ID)
+
+	// Existing notes are indexed for fuzzy search.
+	fuzzy, err := repo.SearchNotes(context.Background(), models.SearchFilters{Fuzzy: "migratoins"})
+	assert.Nil(t, err)
+	assert.Len(t, fuzzy, 1)
+	assert.EqualValues(t, note.ID, fuzzy[0].ID)
+
+	var version int
+	assert.Nil(t, db.GetConnection().QueryRow(sqlGetSchemaVersion).Scan(&version))
+	assert.EqualValues(t, len(sqlMigrations), version)
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"github.com/ricanontherun/short-form/models"
+)
+
+// Maintainer is implemented by repositories whose underlying storage
+// can be checked and tuned.
+type Maintainer interface {
+	// Check the storage for corruption and orphaned rows, optionally removing the orphans.
+	CheckIntegrity(ctx context.Context, fix bool) (*models.IntegrityReport, error)
+
+	// Rebuild the storage, reclaiming unused space.
+	Vacuum(ctx context.Context) error
+
+	// Refresh the query planner statistics.
+	Optimize(ctx context.Context) error
+
+	// Collect statistics about the stored notes.
+	Stats(ctx context.Context, largestNotes int) (*models.Stats, error)
+}
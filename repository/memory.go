@@ -0,0 +1,539 @@
+package repository
+
+import (
+	"context"
+	"github.com/ricanontherun/short-form/models"
+	"github.com/ricanontherun/short-form/utils"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// noteStore persists the changes made to a memoryRepository.
+// Its methods are called while the repository holds its write lock.
+type noteStore interface {
+	saveNote(note *models.Note) error
+	removeNote(noteId string) error
+}
+
+// memoryRepository keeps notes in memory, persisting changes to its store if it has one.
+// Filters behave the same as they do for sqlRepository.
+type memoryRepository struct {
+	mutex sync.RWMutex
+	notes map[string]*models.Note
+	store noteStore
+}
+
+// NewMemoryRepository creates a repository which persists nothing.
+func NewMemoryRepository() Repository {
+	return newMemoryRepository(nil)
+}
+
+func newMemoryRepository(store noteStore) *memoryRepository {
+	return &memoryRepository{
+		notes: make(map[string]*models.Note),
+		store: store,
+	}
+}
+
+// Store a note, persisting it first. The caller must hold the write lock.
+func (repository *memoryRepository) save(note *models.Note) error {
+	if repository.store != nil {
+		if err := repository.store.saveNote(note); err != nil {
+			return err
+		}
+	}
+
+	repository.notes[note.ID] = note
+	return nil
+}
+
+// Remove a note, persisting the removal first. The caller must hold the write lock.
+func (repository *memoryRepository) remove(noteId string) error {
+	if repository.store != nil {
+		if err := repository.store.removeNote(noteId); err != nil {
+			return err
+		}
+	}
+
+	delete(repository.notes, noteId)
+	return nil
+}
+
+// Copy a note so callers can't modify the stored version.
+func copyNote(note *models.Note, withTags bool) *models.Note {
+	clone := note.Clone()
+	clone.Tags = nil
+
+	if withTags && len(note.Tags) > 0 {
+		clone.Tags = append([]string{}, note.Tags...)
+	}
+
+	clone.Meta = copyMeta(note.Meta)
+
+	return &clone
+}
+
+func copyMeta(meta map[string]string) map[string]string {
+	if len(meta) == 0 {
+		return nil
+	}
+
+	clone := make(map[string]string, len(meta))
+	for key, value := range meta {
+		clone[key] = value
+	}
+
+	return clone
+}
+
+// Check if a note matches every given filter.
+func noteMatchesFilters(note *models.Note, filters models.SearchFilters) bool {
+	if filters.DateRange != nil {
+		from := filters.DateRange.From.Truncate(time.Second)
+		to := filters.DateRange.To.Truncate(time.Second)
+
+		if note.Timestamp.Before(from) || note.Timestamp.After(to) {
+			return false
+		}
+	}
+
+	if len(filters.Tags) > 0 {
+		if filters.TagTree && !noteHasTagWithin(note, filters.Tags) {
+			return false
+		} else if !filters.TagTree && !noteHasAnyTag(note, filters.Tags) {
+			return false
+		}
+	}
+
+	if len(filters.Content) > 0 && !containsFold(note.Content, filters.Content) {
+		return false
+	}
+
+	if len(filters.Regex) > 0 {
+		compiled, err := utils.CompileRegexp(filters.Regex)
+		if err != nil || !compiled.MatchString(note.Content) {
+			return false
+		}
+	}
+
+	if len(filters.Fuzzy) > 0 {
+		if _, matches := models.MatchFuzzy(note.Content, filters.Fuzzy); !matches {
+			return false
+		}
+	}
+
+	if len(filters.Title) > 0 && !containsFold(note.GetTitle(), filters.Title) {
+		return false
+	}
+
+	if !filters.DueBefore.IsZero() && (note.Due.IsZero() || note.Due.After(filters.DueBefore)) {
+		return false
+	}
+
+	if filters.Incomplete && !note.CompletedAt.IsZero() {
+		return false
+	}
+
+	if len(filters.Status) > 0 && !noteHasAnyStatus(note, filters.Status) {
+		return false
+	}
+
+	if filters.Pinned && !note.Pinned {
+		return false
+	}
+
+	for _, filter := range filters.Meta {
+		if !filter.Matches(note.Meta) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Case insensitive substring match, like sqlite's LIKE.
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func noteHasAnyTag(note *models.Note, tags []string) bool {
+	for _, noteTag := range note.Tags {
+		for _, tag := range tags {
+			if strings.EqualFold(noteTag, tag) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func noteHasAnyStatus(note *models.Note, statuses []models.Status) bool {
+	for _, status := range statuses {
+		if note.Status == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+func noteHasTagWithin(note *models.Note, roots []string) bool {
+	for _, noteTag := range note.Tags {
+		for _, root := range roots {
+			if models.TagWithin(noteTag, root) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (repository *memoryRepository) WriteNote(ctx context.Context, note models.Note) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if _, exists := repository.notes[note.ID]; exists {
+		return ErrNoteExists
+	}
+
+	return repository.save(copyNote(&note, true))
+}
+
+func (repository *memoryRepository) SearchNotes(ctx context.Context, filters models.SearchFilters) ([]*models.Note, error) {
+	var notes []*models.Note
+
+	err := repository.SearchNotesFunc(ctx, filters, func(note *models.Note) error {
+		notes = append(notes, note)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+func (repository *memoryRepository) SearchNotesFunc(ctx context.Context, filters models.SearchFilters, fn NoteFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Collect matches up front, so fn is free to use the repository.
+	repository.mutex.RLock()
+	matches := make([]*models.Note, 0)
+	for _, note := range repository.notes {
+		if noteMatchesFilters(note, filters) {
+			matches = append(matches, copyNote(note, true))
+		}
+	}
+	repository.mutex.RUnlock()
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Pinned != matches[j].Pinned {
+			return matches[i].Pinned
+		}
+
+		return matches[i].Timestamp.Before(matches[j].Timestamp)
+	})
+
+	for _, note := range matches {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := fn(note); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repository *memoryRepository) DeleteNote(ctx context.Context, noteId string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if _, exists := repository.notes[noteId]; !exists {
+		return ErrNoteNotFound
+	}
+
+	return repository.remove(noteId)
+}
+
+func (repository *memoryRepository) LookupNote(ctx context.Context, noteId string) (*models.Note, error) {
+	return repository.lookupNote(ctx, noteId, false)
+}
+
+func (repository *memoryRepository) LookupNoteWithTags(ctx context.Context, noteId string) (*models.Note, error) {
+	return repository.lookupNote(ctx, noteId, true)
+}
+
+func (repository *memoryRepository) lookupNote(ctx context.Context, noteId string, withTags bool) (*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	if note, exists := repository.notes[noteId]; exists {
+		return copyNote(note, withTags), nil
+	}
+
+	return nil, ErrNoteNotFound
+}
+
+func (repository *memoryRepository) UpdateNote(ctx context.Context, note models.Note) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	stored, exists := repository.notes[note.ID]
+	if !exists {
+		return ErrFailedToUpdateNote
+	}
+
+	updated := copyNote(stored, true)
+	updated.Content = note.Content
+	updated.Title = note.Title
+	updated.Meta = copyMeta(note.Meta)
+	updated.Due = note.Due
+	updated.UpdatedAt = time.Now()
+
+	return repository.save(updated)
+}
+
+func (repository *memoryRepository) SetNoteStatus(ctx context.Context, noteId string, status models.Status, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	stored, exists := repository.notes[noteId]
+	if !exists {
+		return ErrNoteNotFound
+	}
+
+	updated := copyNote(stored, true)
+	updated.Status = status
+	updated.CompletedAt = time.Time{}
+	if status.IsClosed() {
+		updated.CompletedAt = at
+	}
+	updated.UpdatedAt = time.Now()
+
+	return repository.save(updated)
+}
+
+func (repository *memoryRepository) PinNote(ctx context.Context, noteId string, pinned bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	stored, exists := repository.notes[noteId]
+	if !exists {
+		return ErrNoteNotFound
+	}
+
+	updated := copyNote(stored, true)
+	updated.Pinned = pinned
+
+	return repository.save(updated)
+}
+
+func (repository *memoryRepository) TagNote(ctx context.Context, note models.Note, tags []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	stored, exists := repository.notes[note.ID]
+	if !exists {
+		return ErrNoteNotFound
+	}
+
+	updated := copyNote(stored, true)
+	updated.Tags = append([]string{}, tags...)
+	updated.UpdatedAt = time.Now()
+
+	return repository.save(updated)
+}
+
+func (repository *memoryRepository) ListTags(ctx context.Context) ([]models.TagCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	repository.mutex.RLock()
+	counts := make(map[string]int)
+	for _, note := range repository.notes {
+		for _, tag := range utils.SliceUniqueStrings(note.Tags) {
+			counts[tag]++
+		}
+	}
+	repository.mutex.RUnlock()
+
+	tags := make([]models.TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, models.TagCount{Tag: tag, Count: count})
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	return tags, nil
+}
+
+func (repository *memoryRepository) RenameTag(ctx context.Context, from string, to string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	noteCount := 0
+
+	for _, stored := range repository.notes {
+		tags := make([]string, 0, len(stored.Tags))
+		renamed := false
+
+		for _, tag := range stored.Tags {
+			tag, tagRenamed := models.RenameTagTree(tag, from, to)
+			renamed = renamed || tagRenamed
+			tags = append(tags, tag)
+		}
+
+		if !renamed {
+			continue
+		}
+
+		updated := copyNote(stored, true)
+		updated.Tags = utils.SliceUniqueStrings(tags)
+
+		if err := repository.save(updated); err != nil {
+			return noteCount, err
+		}
+
+		noteCount++
+	}
+
+	return noteCount, nil
+}
+
+func (repository *memoryRepository) Links(ctx context.Context, noteId string) ([]models.Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	note, exists := repository.notes[noteId]
+	if !exists {
+		return nil, ErrNoteNotFound
+	}
+
+	targets := models.ExtractLinks(note.Content)
+	links := make([]models.Link, 0, len(targets))
+
+	for _, target := range targets {
+		link := models.Link{Target: target}
+
+		if linked, exists := repository.notes[target]; exists {
+			link.Note = copyNote(linked, false)
+		} else {
+			var matches []*models.Note
+			for _, candidate := range repository.notes {
+				if models.LinksTo(target, candidate.ID) {
+					matches = append(matches, candidate)
+				}
+			}
+
+			if len(matches) == 1 {
+				link.Note = copyNote(matches[0], false)
+			} else {
+				link.Ambiguous = len(matches) > 1
+			}
+		}
+
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+func (repository *memoryRepository) Backlinks(ctx context.Context, noteId string) ([]*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	repository.mutex.RLock()
+	notes := make([]*models.Note, 0)
+	for _, note := range repository.notes {
+		for _, target := range models.ExtractLinks(note.Content) {
+			if models.LinksTo(target, noteId) {
+				notes = append(notes, copyNote(note, true))
+				break
+			}
+		}
+	}
+	repository.mutex.RUnlock()
+
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].Timestamp.Before(notes[j].Timestamp)
+	})
+
+	return notes, nil
+}
+
+func (repository *memoryRepository) LatestNotes(ctx context.Context, limit int) ([]*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	repository.mutex.RLock()
+	notes := make([]*models.Note, 0, len(repository.notes))
+	for _, note := range repository.notes {
+		notes = append(notes, copyNote(note, false))
+	}
+	repository.mutex.RUnlock()
+
+	sort.Slice(notes, func(i, j int) bool {
+		if !notes[i].Timestamp.Equal(notes[j].Timestamp) {
+			return notes[i].Timestamp.After(notes[j].Timestamp)
+		}
+
+		return notes[i].ID < notes[j].ID
+	})
+
+	if len(notes) > limit {
+		notes = notes[:limit]
+	}
+
+	return notes, nil
+}
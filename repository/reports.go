@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"github.com/ricanontherun/short-form/models"
+	"time"
+)
+
+// Reporter is implemented by repositories which can summarise notes with aggregate queries,
+// rather than by reading every note.
+type Reporter interface {
+	// Summarise the notes written between since and until.
+	Report(ctx context.Context, since time.Time, until time.Time) (*models.Report, error)
+}
@@ -1,25 +1,61 @@
 package repository
 
-import "github.com/ricanontherun/short-form/models"
+import (
+	"context"
+	"github.com/ricanontherun/short-form/models"
+	"time"
+)
+
+// NoteFunc is called for each note found by a search, in order.
+// Returning an error stops the search, and is returned from it.
+type NoteFunc func(note *models.Note) error
 
 // Repository Interface.
+// Every method accepts a context which, when cancelled, aborts the operation
+// and rolls back any changes it was making.
 type Repository interface {
 	// Write a new note to the database
-	WriteNote(note models.Note) error
+	WriteNote(ctx context.Context, note models.Note) error
 
 	// Search for notes by tag, date or content
-	SearchNotes(ctx models.SearchFilters) ([]*models.Note, error)
+	SearchNotes(ctx context.Context, filters models.SearchFilters) ([]*models.Note, error)
+
+	// Search for notes, calling fn for each note as it's read rather than loading them all.
+	SearchNotesFunc(ctx context.Context, filters models.SearchFilters, fn NoteFunc) error
 
 	// Delete a note (hard delete)
-	DeleteNote(noteId string) error
+	DeleteNote(ctx context.Context, noteId string) error
 
 	// Fetch a single note from the database
-	LookupNote(noteId string) (*models.Note, error)
+	LookupNote(ctx context.Context, noteId string) (*models.Note, error)
 
-	LookupNoteWithTags(noteId string) (*models.Note, error)
+	LookupNoteWithTags(ctx context.Context, noteId string) (*models.Note, error)
 
 	// Update a note.
-	UpdateNote(note models.Note) error
+	UpdateNote(ctx context.Context, note models.Note) error
+
+	TagNote(ctx context.Context, note models.Note, tags []string) error
+
+	// Set a note's task status. Closing a task, see models.Status.IsClosed, records
+	// when it was closed, and reopening it clears that.
+	SetNoteStatus(ctx context.Context, noteId string, status models.Status, at time.Time) error
+
+	// Pin or unpin a note. Searches list pinned notes first.
+	PinNote(ctx context.Context, noteId string, pinned bool) error
+
+	// List every tag in use, with how many notes have it, sorted by tag.
+	ListTags(ctx context.Context) ([]models.TagCount, error)
+
+	// Rename a tag and every tag beneath it, see models.RenameTagTree.
+	// Returns how many notes were retagged.
+	RenameTag(ctx context.Context, from string, to string) (int, error)
+
+	// Resolve the links written in a note's content, see models.ExtractLinks.
+	Links(ctx context.Context, noteId string) ([]models.Link, error)
+
+	// Find the notes which link to a note.
+	Backlinks(ctx context.Context, noteId string) ([]*models.Note, error)
 
-	TagNote(note models.Note, tags []string) error
+	// List the most recently written notes, newest first, without their tags.
+	LatestNotes(ctx context.Context, limit int) ([]*models.Note, error)
 }
@@ -1,10 +1,14 @@
 package repository
 
 import (
+	"context"
 	"github.com/ricanontherun/short-form/models"
 	"github.com/stretchr/testify/mock"
+	"time"
 )
 
+// mockRepository records calls without their context, so expectations
+// only need to be set up for the remaining arguments.
 type mockRepository struct {
 	mock.Mock
 }
@@ -13,42 +17,115 @@ func NewMockRepository() mockRepository {
 	return mockRepository{}
 }
 
-func (repository *mockRepository) WriteNote(note models.Note) error {
+func (repository *mockRepository) WriteNote(ctx context.Context, note models.Note) error {
 	return repository.Called(note).Error(0)
 }
 
-func (repository *mockRepository) SearchNotes(ctx models.SearchFilters) ([]*models.Note, error) {
-	args := repository.Called(ctx)
+func (repository *mockRepository) SearchNotes(ctx context.Context, filters models.SearchFilters) ([]*models.Note, error) {
+	args := repository.Called(filters)
 
 	notesArgs := args.Get(0)
 
 	if notesArgs != nil {
-		return args.Get(0).([]*models.Note), args.Error(0)
+		return args.Get(0).([]*models.Note), args.Error(1)
 	} else {
-		return nil, args.Error(0)
+		return nil, args.Error(1)
 	}
 }
 
-func (repository *mockRepository) DeleteNote(noteId string) error {
+func (repository *mockRepository) SearchNotesFunc(ctx context.Context, filters models.SearchFilters, fn NoteFunc) error {
+	args := repository.Called(filters)
+
+	if notes, ok := args.Get(0).([]*models.Note); ok {
+		for _, note := range notes {
+			if err := fn(note); err != nil {
+				return err
+			}
+		}
+	}
+
+	return args.Error(1)
+}
+
+func (repository *mockRepository) DeleteNote(ctx context.Context, noteId string) error {
 	return repository.Called(noteId).Error(0)
 }
 
-func (repository *mockRepository) LookupNote(noteId string) (*models.Note, error) {
-	repository.Called(noteId)
-	return nil, nil
+func (repository *mockRepository) LookupNote(ctx context.Context, noteId string) (*models.Note, error) {
+	return repository.lookupNote(repository.Called(noteId))
 }
 
-func (repository *mockRepository) LookupNoteWithTags(noteId string) (*models.Note, error) {
+func (repository *mockRepository) LookupNoteWithTags(ctx context.Context, noteId string) (*models.Note, error) {
+	return repository.lookupNote(repository.Called(noteId))
+}
+
+func (repository *mockRepository) lookupNote(args mock.Arguments) (*models.Note, error) {
+	if note, ok := args.Get(0).(*models.Note); ok {
+		return note, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (repository *mockRepository) UpdateNote(ctx context.Context, note models.Note) error {
+	return repository.Called(note).Error(0)
+}
+
+func (repository *mockRepository) TagNote(ctx context.Context, note models.Note, tags []string) error {
+	return repository.Called(note, tags).Error(0)
+}
+
+func (repository *mockRepository) SetNoteStatus(ctx context.Context, noteId string, status models.Status, at time.Time) error {
+	return repository.Called(noteId, status).Error(0)
+}
+
+func (repository *mockRepository) PinNote(ctx context.Context, noteId string, pinned bool) error {
+	return repository.Called(noteId, pinned).Error(0)
+}
+
+func (repository *mockRepository) ListTags(ctx context.Context) ([]models.TagCount, error) {
+	args := repository.Called()
+
+	if tags, ok := args.Get(0).([]models.TagCount); ok {
+		return tags, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (repository *mockRepository) RenameTag(ctx context.Context, from string, to string) (int, error) {
+	args := repository.Called(from, to)
+	return args.Int(0), args.Error(1)
+}
+
+func (repository *mockRepository) Links(ctx context.Context, noteId string) ([]models.Link, error) {
 	args := repository.Called(noteId)
-	return args.Get(0).(*models.Note), args.Error(1)
+
+	if links, ok := args.Get(0).([]models.Link); ok {
+		return links, args.Error(1)
+	}
+
+	return nil, args.Error(1)
 }
 
-func (repository *mockRepository) UpdateNote(note models.Note) error {
-	return nil
+func (repository *mockRepository) Backlinks(ctx context.Context, noteId string) ([]*models.Note, error) {
+	args := repository.Called(noteId)
+
+	if notes, ok := args.Get(0).([]*models.Note); ok {
+		return notes, args.Error(1)
+	}
+
+	return nil, args.Error(1)
 }
 
-func (repository *mockRepository) TagNote(note models.Note, tags []string) error {
-	return nil
+func (repository *mockRepository) LatestNotes(ctx context.Context, limit int) ([]*models.Note, error) {
+	args := repository.Called(limit)
+
+	if notes, ok := args.Get(0).([]*models.Note); ok {
+		return notes, args.Error(1)
+	}
+
+	return nil, args.Error(1)
 }
 
 func (repository *mockRepository) Close() {
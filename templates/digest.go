@@ -0,0 +1,119 @@
+package templates
+
+import (
+	"github.com/ricanontherun/short-form/models"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// DigestName is the name of the templates which override the default digests, e.g digest.md.
+const DigestName = "digest"
+
+// Formats a digest can be rendered in, which are also the extensions of their templates.
+const (
+	DigestMarkdown = "md"
+	DigestHTML     = "html"
+)
+
+const defaultDigestMarkdown = `
+{{- define "notes"}}{{$day := .Day}}{{range .Notes}}
+- **{{if $day}}{{.Timestamp.Format "15:04"}}{{else}}{{.Timestamp.Format "Jan 2 15:04"}}{{end}}**
+  {{- if .Title}} **{{.Title}}**{{end}}
+  {{- if .Tags}} _{{join .Tags ", "}}_{{end}} {{indent 2 .Content}}
+{{- end}}{{end -}}
+
+# Notes from {{.From.Format "Jan 2"}} to {{.To.Format "Jan 2 2006"}}
+{{range .Groups}}
+## {{.Name}}
+{{- if .Notes}}
+{{template "notes" .}}
+{{- end}}
+{{- range .Groups}}
+
+### {{.Name}}
+{{template "notes" .}}
+{{- end}}
+{{end}}
+{{- if not .Groups}}
+No notes.
+{{end}}`
+
+const defaultDigestHTML = `
+{{- define "notes"}}{{$day := .Day}}<ul>
+{{- range .Notes}}
+<li>
+<strong>{{if $day}}{{.Timestamp.Format "15:04"}}{{else}}{{.Timestamp.Format "Jan 2 15:04"}}{{end}}</strong>
+{{- if .Title}} <strong>{{.Title}}</strong>{{end}}
+{{- if .Tags}} <em>{{join .Tags ", "}}</em>{{end}}
+<p style="white-space: pre-wrap">{{.Content}}</p>
+</li>
+{{- end}}
+</ul>{{end -}}
+
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Notes from {{.From.Format "Jan 2"}} to {{.To.Format "Jan 2 2006"}}</title>
+</head>
+<body>
+<h1>Notes from {{.From.Format "Jan 2"}} to {{.To.Format "Jan 2 2006"}}</h1>
+{{- range .Groups}}
+<h2>{{.Name}}</h2>
+{{- if .Notes}}
+{{template "notes" .}}
+{{- end}}
+{{- range .Groups}}
+<h3>{{.Name}}</h3>
+{{template "notes" .}}
+{{- end}}
+{{- else}}
+<p>No notes.</p>
+{{- end}}
+</body>
+</html>
+`
+
+// Functions available to digest templates.
+var digestFuncs = template.FuncMap{
+	"join": strings.Join,
+
+	// Indent every line after the first, e.g to keep content within a Markdown list item.
+	"indent": func(spaces int, text string) string {
+		return strings.Replace(text, "\n", "\n"+strings.Repeat(" ", spaces), -1)
+	},
+}
+
+// RenderDigest renders a digest in a format, with the user's template for that format
+// when there is one, e.g digest.html.
+func (store Store) RenderDigest(digest *models.Digest, format string) (string, error) {
+	var text string
+
+	switch format {
+	case DigestMarkdown:
+		text = defaultDigestMarkdown
+	case DigestHTML:
+		text = defaultDigestHTML
+	default:
+		return "", ErrInvalidDigestFormat
+	}
+
+	override, err := ioutil.ReadFile(store.digestPath(format))
+	if err == nil {
+		text = string(override)
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	if format == DigestHTML {
+		return RenderHTML(DigestName, text, digestFuncs, digest)
+	}
+
+	return Render(DigestName, text, digestFuncs, digest)
+}
+
+func (store Store) digestPath(format string) string {
+	return strings.TrimSuffix(store.Path(DigestName), templateExtension) + "." + format
+}
@@ -0,0 +1,80 @@
+package templates
+
+import (
+	"strings"
+)
+
+const frontMatterDelimiter = "---"
+
+// Entry is a note as written in a template, or in the editor. Its title and tags
+// are given in an optional front matter block, followed by its content, e.g
+//
+//	---
+//	title: Standup
+//	tags: work, standup
+//	---
+//
+//	Yesterday:
+type Entry struct {
+	Title   string
+	Tags    []string
+	Content string
+}
+
+// ParseEntry reads an entry. Text without front matter is all content.
+func ParseEntry(text string) Entry {
+	var entry Entry
+
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	if strings.TrimSpace(lines[0]) != frontMatterDelimiter {
+		entry.Content = strings.TrimSpace(text)
+		return entry
+	}
+
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+
+		if strings.TrimSpace(line) == frontMatterDelimiter {
+			entry.Content = strings.TrimSpace(strings.Join(lines[i+1:], "\n"))
+			return entry
+		}
+
+		separator := strings.Index(line, ":")
+		if separator == -1 {
+			continue
+		}
+
+		value := strings.TrimSpace(line[separator+1:])
+
+		switch strings.TrimSpace(line[:separator]) {
+		case "title":
+			entry.Title = value
+		case "tags":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); len(tag) > 0 {
+					entry.Tags = append(entry.Tags, tag)
+				}
+			}
+		}
+	}
+
+	// An unterminated block isn't front matter.
+	entry.Title = ""
+	entry.Tags = nil
+	entry.Content = strings.TrimSpace(text)
+	return entry
+}
+
+// Format an entry for editing. The front matter is always written, so the title and tags
+// can be filled in.
+func (entry Entry) Format() string {
+	var builder strings.Builder
+
+	builder.WriteString(frontMatterDelimiter + "\n")
+	builder.WriteString("title: " + entry.Title + "\n")
+	builder.WriteString("tags: " + strings.Join(entry.Tags, ", ") + "\n")
+	builder.WriteString(frontMatterDelimiter + "\n\n")
+	builder.WriteString(entry.Content + "\n")
+
+	return builder.String()
+}
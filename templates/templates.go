@@ -0,0 +1,153 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Templates are Markdown files, named after the template.
+const templateExtension = ".md"
+
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrTemplateExists   = errors.New("template already exists")
+	ErrInvalidName      = errors.New("invalid template name, use letters, numbers, - and _ other than digest")
+
+	ErrInvalidDigestFormat = errors.New("invalid digest format, expected md or html")
+)
+
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Check a note template's name. The digest's name is reserved, since its template shares the directory.
+func isValidName(name string) bool {
+	return validName.MatchString(name) && !strings.EqualFold(name, DigestName)
+}
+
+// Store reads and writes templates kept in a directory.
+type Store struct {
+	directory string
+}
+
+func NewStore(directory string) Store {
+	return Store{directory: directory}
+}
+
+// Path returns where a template is, or would be, stored.
+func (store Store) Path(name string) string {
+	return filepath.Join(store.directory, name+templateExtension)
+}
+
+// List the names of every template, sorted. A missing directory has no templates.
+func (store Store) List() ([]string, error) {
+	files, err := ioutil.ReadDir(store.directory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var names []string
+	for _, file := range files {
+		// The digest template isn't for writing notes.
+		if file.Name() == DigestName+templateExtension {
+			continue
+		}
+
+		if !file.IsDir() && strings.HasSuffix(file.Name(), templateExtension) {
+			names = append(names, strings.TrimSuffix(file.Name(), templateExtension))
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// Read a template's text.
+func (store Store) Read(name string) (string, error) {
+	if !isValidName(name) {
+		return "", ErrInvalidName
+	}
+
+	data, err := ioutil.ReadFile(store.Path(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrTemplateNotFound
+		}
+
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// Create a new template, creating the directory if needed. Existing templates aren't overwritten.
+func (store Store) Create(name string, text string) error {
+	if !isValidName(name) {
+		return ErrInvalidName
+	}
+
+	if err := os.MkdirAll(store.directory, 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(store.Path(name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return ErrTemplateExists
+		}
+
+		return err
+	}
+
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Render a template's text with text/template, making funcs and data available to it.
+func Render(name string, text string, funcs template.FuncMap, data interface{}) (string, error) {
+	parsed, err := template.New(name).Option("missingkey=error").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %s", err.Error())
+	}
+
+	return execute(parsed, data)
+}
+
+// RenderHTML renders a template like Render, with html/template, escaping the data it's given.
+func RenderHTML(name string, text string, funcs template.FuncMap, data interface{}) (string, error) {
+	parsed, err := htmltemplate.New(name).Option("missingkey=error").Funcs(htmltemplate.FuncMap(funcs)).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %s", err.Error())
+	}
+
+	return execute(parsed, data)
+}
+
+type executor interface {
+	Execute(writer io.Writer, data interface{}) error
+}
+
+func execute(parsed executor, data interface{}) (string, error) {
+	var buffer bytes.Buffer
+	if err := parsed.Execute(&buffer, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %s", err.Error())
+	}
+
+	return buffer.String(), nil
+}
@@ -0,0 +1,125 @@
+package templates
+
+import (
+	"github.com/ricanontherun/short-form/models"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	directory, err := ioutil.TempDir("", "sf-templates")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+
+	// Templates live in a directory which is created as needed.
+	store := NewStore(filepath.Join(directory, "templates"))
+
+	names, err := store.List()
+	assert.Nil(t, err)
+	assert.Empty(t, names)
+
+	assert.Nil(t, store.Create("standup", "Yesterday:\n"))
+	assert.Nil(t, store.Create("incident", "Impact:\n"))
+	assert.EqualValues(t, ErrTemplateExists, store.Create("standup", "Today:\n"))
+	assert.EqualValues(t, ErrInvalidName, store.Create("../escape", "text"))
+	assert.EqualValues(t, ErrInvalidName, store.Create("digest", "text"))
+	_, err = store.Read("Digest")
+	assert.EqualValues(t, ErrInvalidName, err)
+
+	names, err = store.List()
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"incident", "standup"}, names)
+
+	text, err := store.Read("standup")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "Yesterday:\n", text)
+
+	_, err = store.Read("missing")
+	assert.EqualValues(t, ErrTemplateNotFound, err)
+}
+
+func TestRender(t *testing.T) {
+	funcs := template.FuncMap{
+		"date":   func() string { return "2019-12-08" },
+		"prompt": func(message string) string { return strings.TrimSuffix(message, "?") + "-42" },
+	}
+
+	rendered, err := Render("standup", `Standup {{date}}, {{prompt "Ticket?"}}`, funcs, nil)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "Standup 2019-12-08, Ticket-42", rendered)
+
+	_, err = Render("broken", "{{unknown}}", funcs, nil)
+	assert.NotNil(t, err)
+
+	rendered, err = RenderHTML("digest", `<p>{{.}}</p>`, funcs, "<script>")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "<p>&lt;script&gt;</p>", rendered)
+}
+
+func TestParseEntry(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected Entry
+	}{
+		{
+			"---\ntitle: Standup\ntags: work, standup\n---\n\nYesterday:\n",
+			Entry{Title: "Standup", Tags: []string{"work", "standup"}, Content: "Yesterday:"},
+		},
+		{"no front matter\n", Entry{Content: "no front matter"}},
+		{"---\ntitle: \ntags: \n---\n\n", Entry{}},
+		{"---\nunterminated", Entry{Content: "---\nunterminated"}},
+	}
+
+	for _, test := range tests {
+		assert.EqualValues(t, test.expected, ParseEntry(test.text), test.text)
+	}
+
+	entry := Entry{Title: "Standup", Tags: []string{"work"}, Content: "Yesterday:"}
+	assert.EqualValues(t, entry, ParseEntry(entry.Format()))
+}
+
+func TestStore_RenderDigest(t *testing.T) {
+	directory, err := ioutil.TempDir("", "sf-templates")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+
+	store := NewStore(directory)
+	note := models.NewNote([]string{"work"}, "first line\nsecond line")
+	note.Timestamp = time.Date(2019, 12, 9, 9, 30, 0, 0, time.Local)
+
+	digest := &models.Digest{
+		From:      time.Date(2019, 12, 2, 0, 0, 0, 0, time.Local),
+		To:        time.Date(2019, 12, 8, 23, 59, 59, 0, time.Local),
+		NoteCount: 1,
+		Groups:    []models.DigestGroup{{Name: "Monday, December 9", Day: true, Notes: []*models.Note{&note}}},
+	}
+
+	rendered, err := store.RenderDigest(digest, DigestMarkdown)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "# Notes from Dec 2 to Dec 8 2019\n\n## Monday, December 9\n\n- **09:30** _work_ first line\n  second line\n", rendered)
+
+	note.Content = "<b>bold</b>"
+	rendered, err = store.RenderDigest(digest, DigestHTML)
+	assert.Nil(t, err)
+	assert.Contains(t, rendered, "<h2>Monday, December 9</h2>")
+	assert.Contains(t, rendered, "&lt;b&gt;bold&lt;/b&gt;")
+
+	// Templates named after the digest replace the defaults, and aren't listed as note templates.
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(directory, "digest.md"), []byte("{{.NoteCount}} notes"), 0644))
+	rendered, err = store.RenderDigest(digest, DigestMarkdown)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "1 notes", rendered)
+
+	names, err := store.List()
+	assert.Nil(t, err)
+	assert.Empty(t, names)
+
+	_, err = store.RenderDigest(digest, "pdf")
+	assert.EqualValues(t, ErrInvalidDigestFormat, err)
+}
@@ -0,0 +1,22 @@
+package utils
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		size     int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{5 * 1024 * 1024, "5.0 MB"},
+	}
+
+	for _, test := range tests {
+		if actual := FormatBytes(test.size); actual != test.expected {
+			t.Fatalf("expected %d to format as '%s', got '%s'", test.size, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"regexp"
+	"sync"
+)
+
+// How many compiled patterns CompileRegexp keeps before starting over.
+const regexpCacheSize = 32
+
+var regexpCache = struct {
+	sync.Mutex
+	patterns map[string]*regexp.Regexp
+}{patterns: make(map[string]*regexp.Regexp)}
+
+// CompileRegexp compiles a pattern, reusing it when it was compiled recently. Searches match the
+// same pattern against every note, e.g from a SQL function called for each row.
+func CompileRegexp(pattern string) (*regexp.Regexp, error) {
+	regexpCache.Lock()
+	defer regexpCache.Unlock()
+
+	if compiled, exists := regexpCache.patterns[pattern]; exists {
+		return compiled, nil
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(regexpCache.patterns) >= regexpCacheSize {
+		regexpCache.patterns = make(map[string]*regexp.Regexp)
+	}
+
+	regexpCache.patterns[pattern] = compiled
+	return compiled, nil
+}
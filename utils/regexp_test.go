@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCompileRegexp(t *testing.T) {
+	first, err := CompileRegexp(`SF-\d+`)
+	assert.Nil(t, err)
+	assert.True(t, first.MatchString("fixed SF-42"))
+
+	// Patterns are compiled once while they're cached.
+	second, err := CompileRegexp(`SF-\d+`)
+	assert.Nil(t, err)
+	assert.True(t, first == second)
+
+	_, err = CompileRegexp(`(`)
+	assert.NotNil(t, err)
+}
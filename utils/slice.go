@@ -10,3 +10,18 @@ func SliceContainsElement(elem string, array []string) bool {
 
 	return false
 }
+
+// SliceUniqueStrings removes duplicate strings from a slice, keeping the first of each.
+func SliceUniqueStrings(array []string) []string {
+	seen := NewSet()
+	unique := make([]string, 0, len(array))
+
+	for _, elem := range array {
+		if !seen.Has(elem) {
+			seen.Add(elem)
+			unique = append(unique, elem)
+		}
+	}
+
+	return unique
+}
@@ -31,3 +31,18 @@ func TestInArray(t *testing.T) {
 		}
 	}
 }
+
+func TestSliceUniqueStrings(t *testing.T) {
+	unique := SliceUniqueStrings([]string{"b", "a", "b", "c", "a"})
+	expected := []string{"b", "a", "c"}
+
+	if len(unique) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, unique)
+	}
+
+	for i := range expected {
+		if unique[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, unique)
+		}
+	}
+}